@@ -0,0 +1,20 @@
+//go:build go1.21
+
+// Package slog reports log/slog records to Rollbar through a rollrus hook.
+package slog
+
+import (
+	"github.com/benjamindow/rollrus"
+)
+
+// NewRollbarHandler returns a slog.Handler reporting records to Rollbar, see
+// rollrus.NewSlogHandler. The returned handler also exposes Flush and Close.
+func NewRollbarHandler(token, env string, config rollrus.RollrusConfig) *rollrus.SlogHandler {
+	return rollrus.NewSlogHandler(token, env, config)
+}
+
+// NewRollbarHandlerForHook returns a slog.Handler reporting records through
+// hook, see rollrus.NewSlogHandlerForHook.
+func NewRollbarHandlerForHook(hook *rollrus.Hook) *rollrus.SlogHandler {
+	return rollrus.NewSlogHandlerForHook(hook)
+}
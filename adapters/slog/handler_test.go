@@ -0,0 +1,46 @@
+//go:build go1.21
+
+package slog
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/benjamindow/rollrus"
+)
+
+func TestRollbarHandler(t *testing.T) {
+	testHook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	handler := NewRollbarHandlerForHook(testHook.Hook)
+	defer handler.Close()
+
+	logger := slog.New(handler).
+		With("service", "api").
+		WithGroup("req").
+		With("id", 7).
+		WithGroup("user")
+
+	logger.Error("failed", "name", "bob")
+	logger.Info("not reported")
+
+	items := testHook.Entries()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	item := items[0]
+	if item.Level != rollrus.SeverityError || item.Message != "failed" {
+		t.Fatalf("Unexpected item: %+v", item)
+	}
+
+	expected := map[string]string{
+		"service":       "api",
+		"req.id":        "7",
+		"req.user.name": "bob",
+	}
+	for k, v := range expected {
+		if item.Fields[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, item.Fields[k])
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package zap reports zap log entries to Rollbar through a rollrus hook.
+package zap
+
+import (
+	"github.com/benjamindow/rollrus"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap/zapcore"
+)
+
+// ZapCore is a zapcore.Core reporting the entries written to it to Rollbar
+// through a rollrus Hook, sharing its buffer, workers and configuration. Tee
+// it with the core that writes the logs:
+//
+//	core := NewZapCore(token, env, rollrus.RollrusConfig{})
+//	logger := zap.New(zapcore.NewTee(logCore, core))
+type ZapCore struct {
+	hook   *rollrus.Hook
+	fields logrus.Fields
+}
+
+// NewZapCore sets up a new core with specified reporting levels.
+func NewZapCore(token string, env string, config rollrus.RollrusConfig) *ZapCore {
+	return NewZapCoreForHook(rollrus.NewHookForLevels(token, env, config))
+}
+
+// NewZapCoreForHook returns a ZapCore reporting through hook, which may also
+// be added to a logrus logger.
+func NewZapCoreForHook(hook *rollrus.Hook) *ZapCore {
+	return &ZapCore{hook: hook}
+}
+
+// levels maps zap levels to logrus levels. DPanic only panics in development,
+// so it's reported as an error.
+var levels = map[zapcore.Level]logrus.Level{
+	zapcore.DebugLevel:  logrus.DebugLevel,
+	zapcore.InfoLevel:   logrus.InfoLevel,
+	zapcore.WarnLevel:   logrus.WarnLevel,
+	zapcore.ErrorLevel:  logrus.ErrorLevel,
+	zapcore.DPanicLevel: logrus.ErrorLevel,
+	zapcore.PanicLevel:  logrus.PanicLevel,
+	zapcore.FatalLevel:  logrus.FatalLevel,
+}
+
+// Enabled reports whether the hook reports entries at level.
+func (c *ZapCore) Enabled(level zapcore.Level) bool {
+	l, ok := levels[level]
+	if !ok {
+		return false
+	}
+
+	for _, hl := range c.hook.Levels() {
+		if hl == l {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns a core adding fields to the entries it reports.
+func (c *ZapCore) With(fields []zapcore.Field) zapcore.Core {
+	return &ZapCore{hook: c.hook, fields: c.convert(fields)}
+}
+
+// Check adds the core to ce when it reports entries at the level of ent.
+func (c *ZapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write reports ent along with fields.
+func (c *ZapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	data := c.convert(fields)
+	if ent.LoggerName != "" {
+		data["logger"] = ent.LoggerName
+	}
+
+	return c.hook.Fire(&logrus.Entry{
+		Logger:  logrus.StandardLogger(),
+		Data:    data,
+		Level:   levels[ent.Level],
+		Time:    ent.Time,
+		Message: ent.Message,
+	})
+}
+
+// convert returns the fields of the core along with fields. Errors are kept
+// as they are so that the hook reports their type, cause and stack trace.
+func (c *ZapCore) convert(fields []zapcore.Field) logrus.Fields {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType {
+			continue
+		}
+		f.AddTo(enc)
+	}
+
+	data := make(logrus.Fields, len(c.fields)+len(enc.Fields))
+	for k, v := range c.fields {
+		data[k] = v
+	}
+	for k, v := range enc.Fields {
+		data[k] = v
+	}
+	for _, f := range fields {
+		if err, ok := f.Interface.(error); ok && f.Type == zapcore.ErrorType {
+			data[f.Key] = err
+		}
+	}
+	return data
+}
+
+// Sync waits for the buffered entries to be sent, see rollrus.Hook.Flush.
+func (c *ZapCore) Sync() error {
+	return c.hook.Flush()
+}
+
+// Hook returns the rollrus hook entries are reported through.
+func (c *ZapCore) Hook() *rollrus.Hook {
+	return c.hook
+}
+
+// Close closes the rollrus hook, see rollrus.Hook.Close.
+func (c *ZapCore) Close() error {
+	return c.hook.Close()
+}
@@ -0,0 +1,63 @@
+package zap
+
+import (
+	"testing"
+
+	"github.com/benjamindow/rollrus"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestZapCore(t *testing.T) {
+	testHook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	core := NewZapCoreForHook(testHook.Hook)
+	defer core.Close()
+
+	logger := zap.New(core).Named("api").With(zap.String("region", "eu"))
+
+	logger.Error("failed", zap.String("user", "bob"), zap.Int("id", 42), zap.Error(errors.New("boom")))
+	logger.Info("not reported")
+
+	items := testHook.Entries()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	item := items[0]
+	if item.Level != rollrus.SeverityError || item.Message != "failed" {
+		t.Fatalf("Unexpected item: %+v", item)
+	}
+
+	expected := map[string]string{
+		"region":     "eu",
+		"user":       "bob",
+		"id":         "42",
+		"logger":     "api",
+		"error":      "boom",
+		"error.type": "*errors.fundamental",
+	}
+	for k, v := range expected {
+		if item.Fields[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, item.Fields[k])
+		}
+	}
+}
+
+func TestZapCoreLevels(t *testing.T) {
+	testHook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	core := NewZapCoreForHook(testHook.Hook)
+	defer core.Close()
+
+	for level, enabled := range map[zapcore.Level]bool{
+		zapcore.InfoLevel:   false,
+		zapcore.WarnLevel:   false,
+		zapcore.ErrorLevel:  true,
+		zapcore.DPanicLevel: true,
+		zapcore.FatalLevel:  true,
+	} {
+		if core.Enabled(level) != enabled {
+			t.Fatalf("Expected Enabled(%s) to be %t", level, enabled)
+		}
+	}
+}
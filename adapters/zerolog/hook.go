@@ -0,0 +1,138 @@
+// Package zerolog reports zerolog events to Rollbar through a rollrus hook.
+package zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/benjamindow/rollrus"
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+)
+
+// ZerologHook reports the events written to it to Rollbar through a rollrus
+// Hook, sharing its buffer, workers and configuration. It is a
+// zerolog.LevelWriter rather than a zerolog.Hook because hooks can't read the
+// fields of an event, so add it to a logger with zerolog.MultiLevelWriter:
+//
+//	hook := NewZerologHook(token, env, rollrus.RollrusConfig{})
+//	logger := zerolog.New(zerolog.MultiLevelWriter(os.Stderr, hook))
+type ZerologHook struct {
+	hook *rollrus.Hook
+}
+
+// NewZerologHook sets up a new hook with specified reporting levels.
+func NewZerologHook(token string, env string, config rollrus.RollrusConfig) *ZerologHook {
+	return NewZerologHookForHook(rollrus.NewHookForLevels(token, env, config))
+}
+
+// NewZerologHookForHook returns a ZerologHook reporting through hook, which
+// may also be added to a logrus logger.
+func NewZerologHookForHook(hook *rollrus.Hook) *ZerologHook {
+	return &ZerologHook{hook: hook}
+}
+
+var levels = map[zerolog.Level]logrus.Level{
+	zerolog.TraceLevel: logrus.TraceLevel,
+	zerolog.DebugLevel: logrus.DebugLevel,
+	zerolog.InfoLevel:  logrus.InfoLevel,
+	zerolog.WarnLevel:  logrus.WarnLevel,
+	zerolog.ErrorLevel: logrus.ErrorLevel,
+	zerolog.FatalLevel: logrus.FatalLevel,
+	zerolog.PanicLevel: logrus.PanicLevel,
+}
+
+// Write reports the JSON event p at the level of its level field.
+func (z *ZerologHook) Write(p []byte) (int, error) {
+	fields, err := decode(p)
+	if err != nil {
+		return 0, err
+	}
+
+	s, _ := fields[zerolog.LevelFieldName].(string)
+	level, err := zerolog.ParseLevel(s)
+	if err != nil || !z.fires(level) {
+		return len(p), nil
+	}
+	return len(p), z.fire(level, fields)
+}
+
+// WriteLevel reports the JSON event p at level.
+func (z *ZerologHook) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if !z.fires(level) {
+		return len(p), nil
+	}
+
+	fields, err := decode(p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), z.fire(level, fields)
+}
+
+// fires reports whether the hook reports events at level.
+func (z *ZerologHook) fires(level zerolog.Level) bool {
+	l, ok := levels[level]
+	if !ok {
+		return false
+	}
+
+	for _, hl := range z.hook.Levels() {
+		if hl == l {
+			return true
+		}
+	}
+	return false
+}
+
+// fire reports the decoded fields of an event.
+func (z *ZerologHook) fire(level zerolog.Level, fields logrus.Fields) error {
+	entry := &logrus.Entry{
+		Logger: logrus.StandardLogger(),
+		Data:   fields,
+		Level:  levels[level],
+		Time:   time.Now(),
+	}
+	if msg, ok := fields[zerolog.MessageFieldName].(string); ok {
+		entry.Message = msg
+	}
+	if s, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if t, err := time.Parse(zerolog.TimeFieldFormat, s); err == nil {
+			entry.Time = t
+		}
+	}
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+
+	return z.hook.Fire(entry)
+}
+
+// decode returns the fields of the JSON event p, keeping numbers as they were
+// written.
+func decode(p []byte) (logrus.Fields, error) {
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+
+	var fields logrus.Fields
+	if err := d.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Hook returns the rollrus hook events are reported through.
+func (z *ZerologHook) Hook() *rollrus.Hook {
+	return z.hook
+}
+
+// Flush waits for the buffered events to be sent, see rollrus.Hook.Flush.
+func (z *ZerologHook) Flush() error {
+	return z.hook.Flush()
+}
+
+// Close closes the rollrus hook, see rollrus.Hook.Close.
+func (z *ZerologHook) Close() error {
+	return z.hook.Close()
+}
@@ -0,0 +1,58 @@
+package zerolog
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/benjamindow/rollrus"
+	"github.com/rs/zerolog"
+)
+
+func TestZerologHook(t *testing.T) {
+	testHook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	hook := NewZerologHookForHook(testHook.Hook)
+	defer hook.Close()
+
+	logger := zerolog.New(zerolog.MultiLevelWriter(ioutil.Discard, hook)).With().Timestamp().Logger()
+
+	logger.Error().Str("user", "bob").Int64("id", 9007199254740993).Msg("failed")
+	logger.Info().Msg("not reported")
+
+	items := testHook.Entries()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	item := items[0]
+	if item.Level != rollrus.SeverityError || item.Message != "failed" {
+		t.Fatalf("Unexpected item: %+v", item)
+	}
+
+	expected := map[string]string{"user": "bob", "id": "9007199254740993"}
+	for k, v := range expected {
+		if item.Fields[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, item.Fields[k])
+		}
+	}
+
+	for _, k := range []string{zerolog.LevelFieldName, zerolog.MessageFieldName} {
+		if _, ok := item.Fields[k]; ok {
+			t.Fatalf("Expected %s not to be reported as a field", k)
+		}
+	}
+}
+
+func TestZerologHookWrite(t *testing.T) {
+	testHook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	hook := NewZerologHookForHook(testHook.Hook)
+	defer hook.Close()
+
+	if _, err := hook.Write([]byte(`{"level":"fatal","message":"down","region":"eu"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	items := testHook.Entries()
+	if len(items) != 1 || items[0].Level != rollrus.SeverityCritical || items[0].Fields["region"] != "eu" {
+		t.Fatalf("Unexpected items: %+v", items)
+	}
+}
@@ -0,0 +1,25 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// BeforeSendFunc is called with every entry before it's sent to Rollbar and
+// the fields it will be reported with. It returns the fields to report, which
+// may be fields itself modified in place, and false to drop the report.
+type BeforeSendFunc func(entry *log.Entry, fields map[string]string) (map[string]string, bool)
+
+// ComposeBeforeSend returns a BeforeSendFunc calling fns in order, passing the
+// fields returned by each function to the next one. It stops at the first
+// function dropping the report.
+func ComposeBeforeSend(fns ...BeforeSendFunc) BeforeSendFunc {
+	return func(entry *log.Entry, fields map[string]string) (map[string]string, bool) {
+		for _, fn := range fns {
+			var ok bool
+			if fields, ok = fn(entry, fields); !ok {
+				return nil, false
+			}
+		}
+		return fields, true
+	}
+}
@@ -0,0 +1,49 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBeforeSend(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers: 1,
+		BeforeSend: ComposeBeforeSend(
+			func(entry *logrus.Entry, fields map[string]string) (map[string]string, bool) {
+				return fields, entry.Message != "noisy"
+			},
+			func(entry *logrus.Entry, fields map[string]string) (map[string]string, bool) {
+				fields["added"] = "yes"
+				return fields, true
+			},
+		),
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("noisy")
+	log.Error("kept")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	items := client.Items()
+	if len(items) != 1 || items[0].msg != "kept" {
+		t.Fatalf("Expected only the kept entry to be sent, got %v", items)
+	}
+
+	if v := items[0].custom["added"]; v != "yes" {
+		t.Fatal("Expected the field added by BeforeSend, but instead it is: ", v)
+	}
+
+	expected := Stats{Sent: 1, Dropped: 1}
+	if stats := hook.Stats(); stats != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+}
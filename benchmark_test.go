@@ -8,6 +8,7 @@ import (
 
 	"github.com/benjamindow/rollrus/buffer/diode"
 	"github.com/sirupsen/logrus"
+	"github.com/stvp/roll"
 )
 
 const (
@@ -62,3 +63,52 @@ func BenchmarkWithDiodeBuffer(b *testing.B) {
 		rollrusLogger.Error(errorMSG)
 	}
 }
+
+func BenchmarkFire(b *testing.B) {
+	rollrusLogger := logrus.New()
+	rollrusLogger.Out = ioutil.Discard
+	hook := NewHookWithClient(discardClient{}, RollrusConfig{})
+	defer hook.Close()
+
+	rollrusLogger.AddHook(hook)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rollrusLogger.Error(errorMSG)
+	}
+}
+
+func BenchmarkFireWithoutStack(b *testing.B) {
+	rollrusLogger := logrus.New()
+	rollrusLogger.Out = ioutil.Discard
+	hook := NewHookWithClient(discardClient{}, RollrusConfig{DisableStackCapture: true})
+	defer hook.Close()
+
+	rollrusLogger.AddHook(hook)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rollrusLogger.Error(errorMSG)
+	}
+}
+
+// discardClient reports nothing, so that benchmarks measure the hook itself.
+type discardClient struct {
+	roll.Client
+}
+
+func (discardClient) Critical(err error, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (discardClient) CriticalStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (discardClient) Error(err error, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (discardClient) ErrorStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return "", nil
+}
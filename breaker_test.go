@@ -0,0 +1,116 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:                true,
+		CircuitBreakerThreshold: 3,
+		CircuitBreakerCooldown:  50 * time.Millisecond,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 5; i++ {
+		log.Error("failing")
+	}
+
+	if n := len(client.Items()); n != 3 {
+		t.Fatalf("Expected 3 attempts before the circuit opened, got %d", n)
+	}
+
+	if state := hook.CircuitState(); state != CircuitOpen {
+		t.Fatal("Expected the circuit to be open, got: ", state)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	client.mu.Lock()
+	client.err = nil
+	client.mu.Unlock()
+
+	log.Error("probe")
+
+	if state := hook.CircuitState(); state != CircuitClosed {
+		t.Fatal("Expected a successful probe to close the circuit, got: ", state)
+	}
+
+	if n := len(client.Items()); n != 4 {
+		t.Fatalf("Expected the probe to be sent, got %d attempts", n)
+	}
+}
+
+func TestCircuitOpenDrop(t *testing.T) {
+	var mu sync.Mutex
+	var reasons []error
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:                true,
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerCooldown:  time.Hour,
+		OnDrop: func(entry *logrus.Entry, reason error) {
+			mu.Lock()
+			reasons = append(reasons, reason)
+			mu.Unlock()
+		},
+	})
+	defer hook.Close()
+
+	entry := &logrus.Entry{Level: logrus.ErrorLevel, Message: "failing", Data: logrus.Fields{}}
+	if err := hook.Fire(entry); err == nil || err == ErrCircuitOpen {
+		t.Fatal("Expected the delivery error, got: ", err)
+	}
+	if err := hook.Fire(entry); err != ErrCircuitOpen {
+		t.Fatal("Expected Fire to return ErrCircuitOpen, got: ", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reasons) != 2 || reasons[1] != ErrCircuitOpen {
+		t.Fatal("Expected OnDrop to be called with ErrCircuitOpen, got: ", reasons)
+	}
+}
+
+func TestCircuitBreakerProbePanics(t *testing.T) {
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(panickingClient{client}, RollrusConfig{
+		SyncFire:                true,
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerCooldown:  20 * time.Millisecond,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("failing")
+	time.Sleep(30 * time.Millisecond)
+	log.Error("boom")
+
+	if state := hook.CircuitState(); state != CircuitOpen {
+		t.Fatal("Expected a panicking probe to reopen the circuit, got: ", state)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	client.mu.Lock()
+	client.err = nil
+	client.mu.Unlock()
+
+	log.Error("probe")
+
+	if state := hook.CircuitState(); state != CircuitClosed {
+		t.Fatal("Expected a successful probe to close the circuit, got: ", state)
+	}
+}
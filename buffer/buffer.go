@@ -6,9 +6,71 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Buffer holds the entries fired by a hook until a worker is free to send
+// them. Push is called concurrently by the logging goroutines, while Next and
+// Value are only called by the goroutine of the hook handing entries to the
+// workers. Entries are returned in the order they were pushed, unless the
+// buffer documents otherwise.
+//
+// buffertest.TestBuffer checks that an implementation follows this contract.
 type Buffer interface {
+	// Close stops the buffer from accepting entries: later calls to Push
+	// are ignored. Next keeps returning the entries already buffered, and
+	// a Next blocked on an empty buffer returns false.
 	io.Closer
+	// Next waits for an entry and reports whether there is one. It returns
+	// false once the buffer is closed and empty.
 	Next() bool
+	// Push adds entry to the buffer, or applies the OverflowPolicy of the
+	// buffer when it is full.
 	Push(entry *logrus.Entry)
+	// Value returns the entry of the last call to Next.
 	Value() *logrus.Entry
 }
+
+// OverflowPolicy is what a Buffer does when an entry is pushed while it's
+// full.
+type OverflowPolicy int
+
+const (
+	// Block makes Push wait until there is room in the buffer.
+	Block OverflowPolicy = iota
+	// DropNewest discards the entry being pushed.
+	DropNewest
+	// DropOldest evicts the oldest entry in the buffer to make room.
+	DropOldest
+)
+
+// Dropper is implemented by buffers that drop entries when they overflow.
+type Dropper interface {
+	// Dropped returns the number of entries dropped so far.
+	Dropped() int64
+}
+
+// DropNotifier is implemented by buffers that can tell which entries they
+// drop.
+type DropNotifier interface {
+	// NotifyDrop makes the buffer call fn with every entry it drops. It must
+	// be called before the buffer is used.
+	NotifyDrop(fn func(*logrus.Entry))
+}
+
+// Acker is implemented by buffers that can keep entries until they have been
+// delivered, so that they can be replayed after a crash.
+type Acker interface {
+	// Acknowledge makes the buffer keep the entries it returns until they
+	// are acknowledged with Ack. It must be called before the buffer is
+	// used.
+	Acknowledge()
+	// ID returns the ID of the entry returned by Value.
+	ID() string
+	// Ack marks the entry with the given ID as delivered.
+	Ack(id string)
+}
+
+// Lener is implemented by buffers that know how many entries they hold, which
+// may include entries pushed by a previous process.
+type Lener interface {
+	// Len returns the number of entries waiting to be read.
+	Len() int
+}
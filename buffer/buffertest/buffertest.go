@@ -0,0 +1,194 @@
+// Package buffertest provides a buffer.Buffer stepped through by tests and a
+// test suite checking buffer.Buffer implementations.
+package buffertest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/sirupsen/logrus"
+)
+
+// SyncBuffer is an unbounded buffer.Buffer whose Next only returns an entry
+// once Step allows it, so that tests control when a hook hands each entry to
+// its workers. Once closed, Next returns the remaining entries without
+// waiting for Step.
+type SyncBuffer struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	entries []*logrus.Entry
+	steps   int
+	value   *logrus.Entry
+	closed  bool
+}
+
+// NewSyncBuffer returns an empty SyncBuffer.
+func NewSyncBuffer() *SyncBuffer {
+	b := &SyncBuffer{}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Step lets Next return one more entry.
+func (b *SyncBuffer) Step() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.steps++
+	b.cond.Broadcast()
+}
+
+func (b *SyncBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+func (b *SyncBuffer) Next() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for {
+		if len(b.entries) > 0 && (b.steps > 0 || b.closed) {
+			b.value, b.entries = b.entries[0], b.entries[1:]
+			if b.steps > 0 {
+				b.steps--
+			}
+			return true
+		}
+		if b.closed {
+			b.value = nil
+			return false
+		}
+		b.cond.Wait()
+	}
+}
+
+func (b *SyncBuffer) Value() *logrus.Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.value
+}
+
+func (b *SyncBuffer) Push(entry *logrus.Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.entries = append(b.entries, entry)
+	b.cond.Broadcast()
+}
+
+// Len returns the number of entries waiting to be returned by Next.
+func (b *SyncBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+// TestBuffer checks that the buffers returned by newBuffer follow the
+// buffer.Buffer contract. newBuffer must return an empty buffer holding at
+// least size entries.
+func TestBuffer(t *testing.T, newBuffer func(size int) buffer.Buffer) {
+	t.Run("Order", func(t *testing.T) {
+		b := newBuffer(10)
+		for i := 0; i < 3; i++ {
+			b.Push(newEntry(i))
+		}
+		b.Close()
+
+		for i := 0; i < 3; i++ {
+			if !b.Next() {
+				t.Fatalf("Expected %d entries, got %d", 3, i)
+			}
+			if msg := b.Value().Message; msg != fmt.Sprint(i) {
+				t.Fatalf("Expected entry %d, got %s", i, msg)
+			}
+		}
+		if b.Next() {
+			t.Fatal("Expected Next to return false once the buffer is closed and empty")
+		}
+	})
+
+	t.Run("PushAfterClose", func(t *testing.T) {
+		b := newBuffer(10)
+		b.Close()
+		b.Push(newEntry(0))
+
+		if b.Next() {
+			t.Fatal("Expected entries pushed after Close to be ignored")
+		}
+	})
+
+	t.Run("CloseUnblocksNext", func(t *testing.T) {
+		b := newBuffer(10)
+
+		next := make(chan bool)
+		go func() {
+			next <- b.Next()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		b.Close()
+
+		select {
+		case ok := <-next:
+			if ok {
+				t.Fatal("Expected Next to return false once closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected Close to unblock Next")
+		}
+	})
+
+	t.Run("ConcurrentPush", func(t *testing.T) {
+		const pushers, entries = 10, 10
+		b := newBuffer(pushers * entries)
+
+		done := make(chan int)
+		go func() {
+			n := 0
+			for b.Next() {
+				n++
+			}
+			done <- n
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < pushers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < entries; j++ {
+					b.Push(newEntry(j))
+				}
+			}()
+		}
+		wg.Wait()
+		b.Close()
+
+		if n := <-done; n != pushers*entries {
+			t.Fatalf("Expected %d entries, got %d", pushers*entries, n)
+		}
+	})
+}
+
+func newEntry(i int) *logrus.Entry {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	entry := logrus.NewEntry(logger)
+	entry.Time = time.Now()
+	entry.Level = logrus.ErrorLevel
+	entry.Message = fmt.Sprint(i)
+	return entry
+}
@@ -0,0 +1,49 @@
+package buffertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjamindow/rollrus/buffer"
+)
+
+func TestSyncBuffer(t *testing.T) {
+	TestBuffer(t, func(int) buffer.Buffer {
+		return NewSyncBuffer()
+	})
+}
+
+func TestStep(t *testing.T) {
+	b := NewSyncBuffer()
+	b.Push(newEntry(0))
+	b.Push(newEntry(1))
+
+	next := make(chan bool)
+	go func() {
+		for b.Next() {
+			next <- true
+		}
+		close(next)
+	}()
+
+	select {
+	case <-next:
+		t.Fatal("Expected Next to wait for Step")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	b.Step()
+	<-next
+	if msg := b.Value().Message; msg != "0" {
+		t.Fatal("Expected the first entry, got: ", msg)
+	}
+	if n := b.Len(); n != 1 {
+		t.Fatalf("Expected 1 entry left, got %d", n)
+	}
+
+	b.Close()
+	<-next
+	if _, ok := <-next; ok {
+		t.Fatal("Expected Next to return false once the buffer is drained")
+	}
+}
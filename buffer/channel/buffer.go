@@ -1,20 +1,33 @@
 package channel
 
 import (
+	"sync/atomic"
+
+	"github.com/benjamindow/rollrus/buffer"
 	"github.com/sirupsen/logrus"
 )
 
 func NewBuffer(size int) *Buffer {
+	return NewBufferWithPolicy(size, buffer.Block)
+}
+
+// NewBufferWithPolicy returns a buffer of the given size that applies policy
+// when an entry is pushed while it's full.
+func NewBufferWithPolicy(size int, policy buffer.OverflowPolicy) *Buffer {
 	return &Buffer{
 		c:      make(chan *logrus.Entry, size),
 		closed: false,
+		policy: policy,
 	}
 }
 
 type Buffer struct {
-	c      chan *logrus.Entry
-	value  *logrus.Entry
-	closed bool
+	dropped int64
+	c       chan *logrus.Entry
+	value   *logrus.Entry
+	closed  bool
+	policy  buffer.OverflowPolicy
+	onDrop  func(*logrus.Entry)
 }
 
 func (c *Buffer) Close() error {
@@ -34,7 +47,50 @@ func (c *Buffer) Value() *logrus.Entry {
 }
 
 func (c *Buffer) Push(entry *logrus.Entry) {
-	if !c.closed {
+	if c.closed {
+		return
+	}
+
+	switch c.policy {
+	case buffer.DropNewest:
+		select {
+		case c.c <- entry:
+		default:
+			c.drop(entry)
+		}
+	case buffer.DropOldest:
+		for {
+			select {
+			case c.c <- entry:
+				return
+			default:
+			}
+
+			select {
+			case oldest := <-c.c:
+				c.drop(oldest)
+			default:
+			}
+		}
+	default:
 		c.c <- entry
 	}
 }
+
+func (c *Buffer) drop(entry *logrus.Entry) {
+	atomic.AddInt64(&c.dropped, 1)
+	if c.onDrop != nil {
+		c.onDrop(entry)
+	}
+}
+
+// NotifyDrop makes the buffer call fn with every entry dropped because the
+// buffer was full.
+func (c *Buffer) NotifyDrop(fn func(*logrus.Entry)) {
+	c.onDrop = fn
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (c *Buffer) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
@@ -1,9 +1,14 @@
 package channel
 
 import (
+	"io/ioutil"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/buffertest"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
 )
 
 func TestBuffer(t *testing.T) {
@@ -28,3 +33,91 @@ func TestBuffer(t *testing.T) {
 		t.Fatalf("Did not recieve all events from queue. Got %d expected %d", outCount, 4)
 	}
 }
+
+func pushValues(b *Buffer, n int) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	for i := 0; i < n; i++ {
+		b.Push(logrus.NewEntry(dummyLogger).WithField("value", i))
+	}
+}
+
+func popValues(b *Buffer) []int {
+	b.Close()
+
+	var values []int
+	for b.Next() {
+		values = append(values, b.Value().Data["value"].(int))
+	}
+	return values
+}
+
+func TestDropNewest(t *testing.T) {
+	b := NewBufferWithPolicy(2, buffer.DropNewest)
+	pushValues(b, 5)
+
+	if values := popValues(b); !reflect.DeepEqual(values, []int{0, 1}) {
+		t.Fatalf("Expected the oldest entries to be kept, got %v", values)
+	}
+
+	if n := b.Dropped(); n != 3 {
+		t.Fatalf("Expected 3 dropped entries, got %d", n)
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	b := NewBufferWithPolicy(2, buffer.DropOldest)
+	pushValues(b, 5)
+
+	if values := popValues(b); !reflect.DeepEqual(values, []int{3, 4}) {
+		t.Fatalf("Expected the newest entries to be kept, got %v", values)
+	}
+
+	if n := b.Dropped(); n != 3 {
+		t.Fatalf("Expected 3 dropped entries, got %d", n)
+	}
+}
+
+func TestNotifyDrop(t *testing.T) {
+	b := NewBufferWithPolicy(2, buffer.DropOldest)
+	var dropped []int
+	b.NotifyDrop(func(entry *logrus.Entry) {
+		dropped = append(dropped, entry.Data["value"].(int))
+	})
+	pushValues(b, 5)
+
+	if !reflect.DeepEqual(dropped, []int{0, 1, 2}) {
+		t.Fatalf("Expected the oldest entries to be dropped, got %v", dropped)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	b := NewBufferWithPolicy(2, buffer.Block)
+	pushValues(b, 2)
+
+	done := make(chan struct{})
+	go func() {
+		pushValues(b, 1)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected Push to block while the buffer is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	b.Next()
+	<-done
+
+	if n := b.Dropped(); n != 0 {
+		t.Fatalf("Expected no dropped entries, got %d", n)
+	}
+}
+
+func TestBufferContract(t *testing.T) {
+	buffertest.TestBuffer(t, func(size int) buffer.Buffer {
+		return NewBuffer(size)
+	})
+}
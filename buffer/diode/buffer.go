@@ -7,32 +7,37 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync/atomic"
 
 	"github.com/cloudfoundry/go-diodes"
 	"github.com/sirupsen/logrus"
 )
 
+// NewBuffer returns a lossy buffer overwriting the oldest entries when full.
+// Unlike other buffers, Close discards the entries still buffered.
 func NewBuffer(size int) *Buffer {
 	ctx, cancel := context.WithCancel(context.Background())
+	b := &Buffer{
+		close:  cancel,
+		closed: ctx.Done(),
+	}
 
 	alerter := func(missed int) {
+		atomic.AddInt64(&b.dropped, int64(missed))
 		fmt.Fprintf(os.Stderr, "Overwrote %d entries", missed)
 	}
 
 	diode := diodes.NewManyToOne(size, diodes.AlertFunc(alerter))
-	waiter := diodes.NewWaiter(diode, diodes.WithWaiterContext(ctx))
+	b.waiter = diodes.NewWaiter(diode, diodes.WithWaiterContext(ctx))
 
-	return &Buffer{
-		close:  cancel,
-		closed: ctx.Done(),
-		waiter: waiter,
-	}
+	return b
 }
 
 type Buffer struct {
-	waiter *diodes.Waiter
-	close  context.CancelFunc
-	closed <-chan struct{}
+	dropped int64
+	waiter  *diodes.Waiter
+	close   context.CancelFunc
+	closed  <-chan struct{}
 }
 
 func (c *Buffer) Close() error {
@@ -69,3 +74,9 @@ func (c *Buffer) Push(entry *logrus.Entry) {
 		c.waiter.Set(diodes.GenericDataType(entry))
 	}
 }
+
+// Dropped returns the number of entries that were overwritten before being
+// read.
+func (c *Buffer) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
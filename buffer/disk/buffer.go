@@ -0,0 +1,308 @@
+package disk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ext    = ".json"
+	tmpExt = ".tmp"
+)
+
+// record is the part of a logrus entry that is persisted.
+type record struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// NewBuffer returns a buffer persisting entries as JSON files in dir, which
+// is created if needed. Entries left in dir by a previous process are
+// replayed first. Once the files in dir take up maxBytes, new entries are
+// dropped; a maxBytes of 0 means no limit.
+//
+// Without Acknowledge, an entry's file is removed when the next entry is
+// read, which happens as soon as the entry is handed over for delivery, so an
+// entry whose delivery is interrupted by a crash is lost. After Acknowledge,
+// which asynchronous rollrus hooks call, files are kept until their entry is
+// acknowledged instead, and the entries that weren't are replayed by the next
+// process: a crash then at worst reports an entry twice.
+//
+// Only the time, level, message and fields of an entry are persisted, errors
+// as their message. Its Context is lost, so entries read back are reported
+// without the stack of their log call site, the request_id and trace_id
+// context values and the OpenTelemetry trace context.
+func NewBuffer(dir string, maxBytes int64) (*Buffer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Buffer{dir: dir, maxBytes: maxBytes}
+	b.cond = sync.NewCond(&b.mu)
+	for _, info := range infos {
+		// Left behind by a crash while writing an entry.
+		if strings.HasSuffix(info.Name(), ext+tmpExt) {
+			os.Remove(filepath.Join(dir, info.Name()))
+			continue
+		}
+
+		seq, ok := parseName(info.Name())
+		if !ok {
+			continue
+		}
+		b.files = append(b.files, info.Name())
+		b.size += info.Size()
+		if seq >= b.seq {
+			b.seq = seq + 1
+		}
+	}
+	sort.Strings(b.files)
+
+	return b, nil
+}
+
+type Buffer struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	files   []string
+	size    int64
+	seq     uint64
+	closed  bool
+	dropped int64
+	current string
+	value   *logrus.Entry
+	ack     bool
+	onDrop  func(*logrus.Entry)
+}
+
+func parseName(name string) (uint64, bool) {
+	if !strings.HasSuffix(name, ext) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSuffix(name, ext), 10, 64)
+	return seq, err == nil
+}
+
+// Close stops the buffer from accepting entries. Next keeps returning the
+// entries already on disk.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+// Next removes the file of the previous entry, unless entries are
+// acknowledged, waits for the next one and reports whether there was one. It
+// returns false once the buffer is closed and empty.
+func (b *Buffer) Next() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.ack {
+		b.remove(b.current)
+	}
+	b.current = ""
+
+	for {
+		for len(b.files) == 0 && !b.closed {
+			b.cond.Wait()
+		}
+		if len(b.files) == 0 {
+			b.value = nil
+			return false
+		}
+
+		b.current, b.files = b.files[0], b.files[1:]
+		entry, err := b.read(b.current)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read buffered entry %s: %v\n", b.current, err)
+			b.remove(b.current)
+			b.current = ""
+			continue
+		}
+
+		b.value = entry
+		return true
+	}
+}
+
+func (b *Buffer) Value() *logrus.Entry {
+	return b.value
+}
+
+func (b *Buffer) Push(entry *logrus.Entry) {
+	data, err := json.Marshal(record{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Data:    encodeFields(entry.Data),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not encode entry: %v\n", err)
+		return
+	}
+
+	if !b.write(data) && b.onDrop != nil {
+		b.onDrop(entry)
+	}
+}
+
+// write persists an encoded entry and reports whether it was kept.
+func (b *Buffer) write(data []byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return true
+	}
+
+	if b.maxBytes > 0 && b.size+int64(len(data)) > b.maxBytes {
+		b.dropped++
+		return false
+	}
+
+	name := fmt.Sprintf("%020d%s", b.seq, ext)
+	if err := writeFile(filepath.Join(b.dir, name), data); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not persist entry: %v\n", err)
+		b.dropped++
+		return false
+	}
+
+	b.seq++
+	b.size += int64(len(data))
+	b.files = append(b.files, name)
+	b.cond.Signal()
+	return true
+}
+
+// NotifyDrop makes the buffer call fn with every entry dropped because the
+// buffer was full or the entry could not be written.
+func (b *Buffer) NotifyDrop(fn func(*logrus.Entry)) {
+	b.onDrop = fn
+}
+
+// Len returns the number of entries waiting to be read, including the ones
+// replayed from a previous process.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.files)
+}
+
+// Dropped returns the number of entries dropped because the buffer was full
+// or they could not be written.
+func (b *Buffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// Acknowledge makes the buffer keep the file of an entry until it is
+// acknowledged with Ack.
+func (b *Buffer) Acknowledge() {
+	b.ack = true
+}
+
+// ID returns the name of the file of the entry returned by Value.
+func (b *Buffer) ID() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current
+}
+
+// Ack removes the file of a delivered entry.
+func (b *Buffer) Ack(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(id)
+}
+
+// remove deletes the file of an entry, b.mu must be held.
+func (b *Buffer) remove(name string) {
+	if name == "" {
+		return
+	}
+
+	path := filepath.Join(b.dir, name)
+	if info, err := os.Stat(path); err == nil {
+		b.size -= info.Size()
+	}
+	os.Remove(path)
+}
+
+func (b *Buffer) read(name string) (*logrus.Entry, error) {
+	data, err := ioutil.ReadFile(filepath.Join(b.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	level, err := logrus.ParseLevel(r.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logrus.Entry{
+		Logger:  logrus.StandardLogger(),
+		Data:    r.Data,
+		Time:    r.Time,
+		Level:   level,
+		Message: r.Message,
+	}, nil
+}
+
+// writeFile writes data to a temporary file renamed to path, so that a crash
+// never leaves a partial entry behind.
+func writeFile(path string, data []byte) error {
+	tmp := path + tmpExt
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// encodeFields converts the values of fields that can't be encoded as JSON,
+// such as errors, to strings.
+func encodeFields(fields logrus.Fields) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		switch t := v.(type) {
+		case error:
+			m[k] = t.Error()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				m[k] = fmt.Sprintf("%+v", v)
+			} else {
+				m[k] = v
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,190 @@
+package disk
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/buffertest"
+	"github.com/sirupsen/logrus"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rollrus-disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestBufferReplay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b, err := NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		entry := logrus.NewEntry(dummyLogger).WithField("value", i).WithError(errors.New("boom"))
+		entry.Level = logrus.ErrorLevel
+		entry.Message = "persisted"
+		b.Push(entry)
+	}
+	b.Close()
+
+	// A new process picks up where the previous one stopped.
+	b, err = NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+
+	i := 0
+	for b.Next() {
+		entry := b.Value()
+		if entry.Message != "persisted" || entry.Level != logrus.ErrorLevel {
+			t.Fatalf("Unexpected replayed entry: %+v", entry)
+		}
+
+		if v := entry.Data["value"]; v != float64(i) {
+			t.Fatalf("Expected value %d, got %v", i, v)
+		}
+
+		if v := entry.Data[logrus.ErrorKey]; v != "boom" {
+			t.Fatal("Expected the error to be persisted as a string, got: ", v)
+		}
+		i++
+	}
+
+	if i != 3 {
+		t.Fatalf("Expected 3 replayed entries, got %d", i)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Fatalf("Expected read entries to be removed, got %d files", len(files))
+	}
+}
+
+func TestBufferMaxBytes(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b, err := NewBuffer(dir, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		b.Push(logrus.NewEntry(dummyLogger).WithField("value", i))
+	}
+	b.Close()
+
+	n := int64(0)
+	for b.Next() {
+		n++
+	}
+
+	if n == 0 || n+b.Dropped() != 10 {
+		t.Fatalf("Expected entries over the limit to be dropped, got %d read and %d dropped", n, b.Dropped())
+	}
+}
+
+func TestBufferAck(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b, err := NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Acknowledge()
+
+	for i := 0; i < 3; i++ {
+		b.Push(logrus.NewEntry(dummyLogger).WithField("value", i))
+	}
+	b.Close()
+
+	var ids []string
+	for b.Next() {
+		ids = append(ids, b.ID())
+	}
+
+	if len(ids) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(ids))
+	}
+
+	b.Ack(ids[1])
+
+	b, err = NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+
+	var values []interface{}
+	for b.Next() {
+		values = append(values, b.Value().Data["value"])
+	}
+
+	if len(values) != 2 || values[0] != float64(0) || values[1] != float64(2) {
+		t.Fatalf("Expected the unacknowledged entries to be replayed, got %v", values)
+	}
+}
+
+func TestBufferStaleTempFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	stale := filepath.Join(dir, "00000000000000000007.json.tmp")
+	if err := ioutil.WriteFile(stale, []byte(`{"level":"err`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+
+	if b.Next() {
+		t.Fatal("Expected no entry to be replayed, got: ", b.Value())
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatal("Expected the stale temporary file to be removed, got: ", err)
+	}
+}
+
+func TestBufferContract(t *testing.T) {
+	var dirs []string
+	defer func() {
+		for _, dir := range dirs {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	buffertest.TestBuffer(t, func(int) buffer.Buffer {
+		dir := tempDir(t)
+		dirs = append(dirs, dir)
+
+		b, err := NewBuffer(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	})
+}
@@ -0,0 +1,159 @@
+package priority
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewBuffer returns a buffer holding up to size entries which returns the most
+// severe entries first, e.g. Panic and Fatal before Error, and entries of the
+// same level in the order they were pushed. When it's full pushing an entry
+// evicts the oldest entry of the least severe level, or drops the pushed
+// entry if every buffered entry is more severe.
+func NewBuffer(size int) *Buffer {
+	if size < 1 {
+		size = 1
+	}
+
+	b := &Buffer{size: size}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Buffer is safe for concurrent use by many pushing goroutines and a single
+// consumer calling Next and Value.
+type Buffer struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queues holds the entries of each level, most severe first.
+	queues  [logrus.TraceLevel + 1][]*logrus.Entry
+	size    int
+	count   int
+	closed  bool
+	dropped int64
+	value   *logrus.Entry
+	onDrop  func(*logrus.Entry)
+}
+
+// Close stops the buffer from accepting entries. Next keeps returning the
+// entries already in the buffer.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+// Next waits for an entry and reports whether there was one, it returns false
+// once the buffer is closed and empty.
+func (b *Buffer) Next() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.count == 0 && !b.closed {
+		b.cond.Wait()
+	}
+	if b.count == 0 {
+		b.value = nil
+		return false
+	}
+
+	for i, q := range b.queues {
+		if len(q) > 0 {
+			b.value = q[0]
+			q[0] = nil
+			b.queues[i] = q[1:]
+			break
+		}
+	}
+	b.count--
+	return true
+}
+
+func (b *Buffer) Value() *logrus.Entry {
+	return b.value
+}
+
+// Push adds entry to the buffer. When the buffer is full an entry is dropped
+// to make room, so Push never blocks.
+func (b *Buffer) Push(entry *logrus.Entry) {
+	if dropped := b.push(entry); dropped != nil && b.onDrop != nil {
+		b.onDrop(dropped)
+	}
+}
+
+// push adds entry to the buffer and returns the entry it dropped, if any.
+func (b *Buffer) push(entry *logrus.Entry) *logrus.Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	level := entry.Level
+	if level > logrus.TraceLevel {
+		level = logrus.TraceLevel
+	}
+
+	var dropped *logrus.Entry
+	if b.count == b.size {
+		b.dropped++
+		dropped = b.evict(level)
+		if dropped == nil {
+			return entry
+		}
+	}
+
+	b.queues[level] = append(b.queues[level], entry)
+	b.count++
+	b.cond.Signal()
+	return dropped
+}
+
+// evict removes the oldest entry of the least severe level that isn't more
+// severe than level, and returns it. It returns nil when every buffered entry
+// is more severe.
+func (b *Buffer) evict(level logrus.Level) *logrus.Entry {
+	for l := logrus.TraceLevel; l >= level; l-- {
+		q := b.queues[l]
+		if len(q) == 0 {
+			continue
+		}
+
+		evicted := q[0]
+		q[0] = nil
+		b.queues[l] = q[1:]
+		b.count--
+		return evicted
+	}
+	return nil
+}
+
+// NotifyDrop makes the buffer call fn with every entry dropped because the
+// buffer was full.
+func (b *Buffer) NotifyDrop(fn func(*logrus.Entry)) {
+	b.onDrop = fn
+}
+
+// Len returns the number of entries waiting to be read.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Cap returns the number of entries the buffer holds when it's full.
+func (b *Buffer) Cap() int {
+	return b.size
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (b *Buffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
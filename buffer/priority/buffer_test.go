@@ -0,0 +1,115 @@
+package priority
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/buffertest"
+	"github.com/sirupsen/logrus"
+)
+
+func entry(logger *logrus.Logger, level logrus.Level, value int) *logrus.Entry {
+	e := logrus.NewEntry(logger).WithField("value", value)
+	e.Level = level
+	return e
+}
+
+func TestBuffer(t *testing.T) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b := NewBuffer(10)
+	b.Push(entry(dummyLogger, logrus.ErrorLevel, 0))
+	b.Push(entry(dummyLogger, logrus.ErrorLevel, 1))
+	b.Push(entry(dummyLogger, logrus.FatalLevel, 2))
+	b.Push(entry(dummyLogger, logrus.ErrorLevel, 3))
+	b.Push(entry(dummyLogger, logrus.PanicLevel, 4))
+	b.Push(entry(dummyLogger, logrus.FatalLevel, 5))
+	b.Close()
+
+	var values []int
+	for b.Next() {
+		values = append(values, b.Value().Data["value"].(int))
+	}
+
+	if !reflect.DeepEqual(values, []int{4, 2, 5, 0, 1, 3}) {
+		t.Fatalf("Expected the most severe entries first, got %v", values)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b := NewBuffer(2)
+	var dropped []int
+	b.NotifyDrop(func(entry *logrus.Entry) {
+		dropped = append(dropped, entry.Data["value"].(int))
+	})
+
+	b.Push(entry(dummyLogger, logrus.ErrorLevel, 0))
+	b.Push(entry(dummyLogger, logrus.FatalLevel, 1))
+	b.Push(entry(dummyLogger, logrus.FatalLevel, 2))
+	b.Push(entry(dummyLogger, logrus.ErrorLevel, 3))
+	b.Close()
+
+	var values []int
+	for b.Next() {
+		values = append(values, b.Value().Data["value"].(int))
+	}
+
+	if !reflect.DeepEqual(values, []int{1, 2}) {
+		t.Fatalf("Expected the most severe entries to be kept, got %v", values)
+	}
+
+	if !reflect.DeepEqual(dropped, []int{0, 3}) {
+		t.Fatalf("Expected the least severe entries to be dropped, got %v", dropped)
+	}
+
+	if n := b.Dropped(); n != 2 {
+		t.Fatalf("Expected 2 dropped entries, got %d", n)
+	}
+}
+
+func TestConcurrentPush(t *testing.T) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b := NewBuffer(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(level logrus.Level) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Push(entry(dummyLogger, level, j))
+			}
+		}(logrus.Level(i))
+	}
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for b.Next() {
+			n++
+		}
+		done <- n
+	}()
+
+	wg.Wait()
+	b.Close()
+
+	if n := <-done; int64(n)+b.Dropped() != 400 {
+		t.Fatalf("Expected every entry to be read or dropped, got %d read and %d dropped", n, b.Dropped())
+	}
+}
+
+func TestBufferContract(t *testing.T) {
+	buffertest.TestBuffer(t, func(size int) buffer.Buffer {
+		return NewBuffer(size)
+	})
+}
@@ -0,0 +1,127 @@
+package ring
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewBuffer returns a ring buffer holding up to size entries. When it's full
+// pushing an entry evicts the oldest unread one, so that the most recent
+// entries are kept.
+func NewBuffer(size int) *Buffer {
+	if size < 1 {
+		size = 1
+	}
+
+	b := &Buffer{entries: make([]*logrus.Entry, size)}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Buffer is safe for concurrent use by many pushing goroutines and a single
+// consumer calling Next and Value.
+type Buffer struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	entries []*logrus.Entry
+	head    int
+	count   int
+	closed  bool
+	dropped int64
+	value   *logrus.Entry
+	onDrop  func(*logrus.Entry)
+}
+
+// Close stops the buffer from accepting entries. Next keeps returning the
+// entries already in the buffer.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+// Next waits for an entry and reports whether there was one, it returns false
+// once the buffer is closed and empty.
+func (b *Buffer) Next() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.count == 0 && !b.closed {
+		b.cond.Wait()
+	}
+	if b.count == 0 {
+		b.value = nil
+		return false
+	}
+
+	b.value = b.entries[b.head]
+	b.entries[b.head] = nil
+	b.head = (b.head + 1) % len(b.entries)
+	b.count--
+	return true
+}
+
+func (b *Buffer) Value() *logrus.Entry {
+	return b.value
+}
+
+// Push adds entry to the buffer. When the buffer is full the oldest unread
+// entry is evicted to make room, so Push never blocks.
+func (b *Buffer) Push(entry *logrus.Entry) {
+	if evicted := b.push(entry); evicted != nil && b.onDrop != nil {
+		b.onDrop(evicted)
+	}
+}
+
+// push adds entry to the buffer and returns the entry it evicted, if any.
+func (b *Buffer) push(entry *logrus.Entry) *logrus.Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	var evicted *logrus.Entry
+	if b.count == len(b.entries) {
+		evicted = b.entries[b.head]
+		b.entries[b.head] = nil
+		b.head = (b.head + 1) % len(b.entries)
+		b.count--
+		b.dropped++
+	}
+
+	b.entries[(b.head+b.count)%len(b.entries)] = entry
+	b.count++
+	b.cond.Signal()
+	return evicted
+}
+
+// NotifyDrop makes the buffer call fn with every entry evicted to make room
+// for a newer one.
+func (b *Buffer) NotifyDrop(fn func(*logrus.Entry)) {
+	b.onDrop = fn
+}
+
+// Len returns the number of entries waiting to be read.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Cap returns the number of entries the buffer holds when it's full.
+func (b *Buffer) Cap() int {
+	return len(b.entries)
+}
+
+// Dropped returns the number of entries evicted to make room for newer ones.
+func (b *Buffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
@@ -0,0 +1,117 @@
+package ring
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/buffertest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestBuffer(t *testing.T) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b := NewBuffer(3)
+	for i := 0; i < 5; i++ {
+		b.Push(logrus.NewEntry(dummyLogger).WithField("value", i))
+	}
+	b.Close()
+
+	var values []int
+	for b.Next() {
+		values = append(values, b.Value().Data["value"].(int))
+	}
+
+	if !reflect.DeepEqual(values, []int{2, 3, 4}) {
+		t.Fatalf("Expected the newest entries to be kept, got %v", values)
+	}
+
+	if n := b.Dropped(); n != 2 {
+		t.Fatalf("Expected 2 evicted entries, got %d", n)
+	}
+}
+
+func TestLen(t *testing.T) {
+	b := NewBuffer(3)
+	if b.Cap() != 3 {
+		t.Fatalf("Expected a capacity of 3, got %d", b.Cap())
+	}
+
+	for i := 0; i < 5; i++ {
+		b.Push(logrus.NewEntry(logrus.New()))
+		expected := i + 1
+		if expected > 3 {
+			expected = 3
+		}
+		if b.Len() != expected {
+			t.Fatalf("Expected %d entries, got %d", expected, b.Len())
+		}
+	}
+
+	b.Next()
+	if b.Len() != 2 {
+		t.Fatalf("Expected 2 entries after reading one, got %d", b.Len())
+	}
+}
+
+func TestNotifyDrop(t *testing.T) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b := NewBuffer(3)
+	var dropped []int
+	b.NotifyDrop(func(entry *logrus.Entry) {
+		dropped = append(dropped, entry.Data["value"].(int))
+	})
+	for i := 0; i < 5; i++ {
+		b.Push(logrus.NewEntry(dummyLogger).WithField("value", i))
+	}
+
+	if !reflect.DeepEqual(dropped, []int{0, 1}) {
+		t.Fatalf("Expected the evicted entries, got %v", dropped)
+	}
+}
+
+func TestConcurrentPush(t *testing.T) {
+	dummyLogger := logrus.New()
+	dummyLogger.Out = ioutil.Discard
+
+	b := NewBuffer(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Push(logrus.NewEntry(dummyLogger))
+			}
+		}()
+	}
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for b.Next() {
+			n++
+		}
+		done <- n
+	}()
+
+	wg.Wait()
+	b.Close()
+
+	if n := <-done; int64(n)+b.Dropped() != 400 {
+		t.Fatalf("Expected every entry to be read or evicted, got %d read and %d evicted", n, b.Dropped())
+	}
+}
+
+func TestBufferContract(t *testing.T) {
+	buffertest.TestBuffer(t, func(size int) buffer.Buffer {
+		return NewBuffer(size)
+	})
+}
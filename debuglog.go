@@ -0,0 +1,53 @@
+package rollrus
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// InternalField is set on the entries the hook logs itself, e.g. to the
+// DebugLogger. Fire skips entries carrying it, so that a logger the hook was
+// added to doesn't feed the hook's own entries back to it, which would
+// amplify itself during an error storm.
+const InternalField = "__rollrus_internal"
+
+// debugLogger logs the internal events of the hook at the debug level: drops,
+// failed deliveries, retries and shutdown timeouts.
+type debugLogger struct {
+	l log.FieldLogger
+}
+
+func newDebugLogger(l log.FieldLogger) *debugLogger {
+	if l == nil {
+		return nil
+	}
+	return &debugLogger{l: l}
+}
+
+// log logs msg with fields. A nil debugLogger logs nothing.
+func (d *debugLogger) log(msg string, fields log.Fields) {
+	if d == nil {
+		return
+	}
+
+	d.l.WithFields(fields).WithField(InternalField, true).Debug(msg)
+}
+
+type skipKey struct{}
+
+// WithoutReport returns a copy of ctx making the hooks skip the entries
+// logged with it, e.g. log.WithContext(WithoutReport(ctx)), for entries that
+// are reported to Rollbar by other means.
+func WithoutReport(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// internal reports whether entry was logged by the hook itself, possibly to a
+// logger this hook was added to, or with a WithoutReport context.
+func internal(entry *log.Entry) bool {
+	if _, ok := entry.Data[InternalField]; ok {
+		return true
+	}
+	return entry.Context != nil && entry.Context.Value(skipKey{}) != nil
+}
@@ -0,0 +1,78 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestDebugLogger(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Level = logrus.DebugLevel
+	debug := test.NewLocal(log)
+
+	client := &fakeClient{err: errors.New("received response: 503 Service Unavailable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:       true,
+		LogLevels:      logrus.AllLevels,
+		MaxRetries:     1,
+		RetryBaseDelay: time.Millisecond,
+		ErrorLogger:    ioutil.Discard,
+		DebugLogger:    log,
+	})
+	defer hook.Close()
+	log.Hooks.Add(hook)
+
+	log.Error("undeliverable")
+
+	if n := len(client.Items()); n != 2 {
+		t.Fatalf("Expected the debug entries not to be reported, got %d items", n)
+	}
+
+	var msgs []string
+	for _, entry := range debug.AllEntries() {
+		if entry.Level == logrus.DebugLevel {
+			msgs = append(msgs, entry.Message)
+		}
+	}
+
+	if len(msgs) != 2 || msgs[0] != "retrying delivery" || msgs[1] != "entry dropped" {
+		t.Fatalf("Unexpected debug entries: %v", msgs)
+	}
+}
+
+func TestInternalEntriesNotReported(t *testing.T) {
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Level = logrus.DebugLevel
+
+	// Every delivery fails and logs to the logger the hook is added to, so
+	// reporting the hook's own entries would never end.
+	client := &fakeClient{err: errors.New("received response: 503 Service Unavailable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:    true,
+		LogLevels:   logrus.AllLevels,
+		ErrorLogger: ioutil.Discard,
+		DebugLogger: log,
+	})
+	defer hook.Close()
+	log.Hooks.Add(hook)
+
+	log.Error("undeliverable")
+
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected only the original entry to be reported, got %d items", n)
+	}
+
+	if err := hook.Fire(log.WithField(InternalField, true)); err != nil {
+		t.Fatal("Expected Fire to succeed, got: ", err)
+	}
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected entries carrying %s to be skipped, got %d items", InternalField, n)
+	}
+}
@@ -0,0 +1,167 @@
+package rollrus
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultDedupeMaxKeys is the default RollrusConfig.DedupeMaxKeys.
+var defaultDedupeMaxKeys = 1000
+
+// The fields of the report closing a dedupe window, see
+// RollrusConfig.DedupeWindow.
+const (
+	// RepeatCountField holds the number of identical entries logged during
+	// the window, the one reported when it opened included.
+	RepeatCountField = "repeat_count"
+	// SuppressedCountField holds the number of identical entries
+	// suppressed during the window.
+	SuppressedCountField = "suppressed_count"
+)
+
+// DedupeKeyFunc returns the key identifying identical entries, see
+// RollrusConfig.DedupeWindow.
+type DedupeKeyFunc func(entry *log.Entry) string
+
+// defaultDedupeKey returns the DedupeKeyFunc identifying entries by their
+// level, message and fingerprint: their FingerprintField, or else the one
+// returned by fn, if not nil.
+func defaultDedupeKey(fn FingerprintFunc) DedupeKeyFunc {
+	return func(entry *log.Entry) string {
+		fingerprint, ok := entry.Data[FingerprintField]
+		if !ok && fn != nil {
+			fingerprint = callFingerprint(fn, entry)
+		}
+		return fmt.Sprintf("%s:%v:%s", entry.Level, fingerprint, entry.Message)
+	}
+}
+
+// deduper lets the first of identical entries through and suppresses the
+// others for a window, at the end of which it releases the last one with the
+// number of entries suppressed. Once maxKeys windows are open, opening one
+// closes the least recently used.
+type deduper struct {
+	window  time.Duration
+	key     DedupeKeyFunc
+	maxKeys int
+	release func(*log.Entry)
+
+	mu   sync.Mutex
+	seen map[string]*seenEntry
+	// lru holds the keys of seen, the least recently used at the back.
+	lru     *list.List
+	stopped bool
+}
+
+type seenEntry struct {
+	// last is the last suppressed entry, nil until one is.
+	last  *log.Entry
+	count int
+	timer *time.Timer
+	elem  *list.Element
+}
+
+func newDeduper(window time.Duration, key DedupeKeyFunc, maxKeys int, release func(*log.Entry)) *deduper {
+	if maxKeys <= 0 {
+		maxKeys = defaultDedupeMaxKeys
+	}
+
+	return &deduper{
+		window:  window,
+		key:     key,
+		maxKeys: maxKeys,
+		release: release,
+		seen:    make(map[string]*seenEntry),
+		lru:     list.New(),
+	}
+}
+
+// suppress reports whether entry is identical to an entry seen during the
+// current window, in which case it's counted rather than reported. Otherwise
+// it opens a window for the entries identical to it.
+func (d *deduper) suppress(entry *log.Entry) bool {
+	k := d.key(entry)
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return false
+	}
+
+	if s, ok := d.seen[k]; ok {
+		s.last = entry
+		s.count++
+		d.lru.MoveToFront(s.elem)
+		d.mu.Unlock()
+		return true
+	}
+
+	var evicted *seenEntry
+	if len(d.seen) >= d.maxKeys {
+		evicted = d.remove(d.lru.Back().Value.(string))
+		evicted.timer.Stop()
+	}
+
+	s := &seenEntry{elem: d.lru.PushFront(k)}
+	s.timer = time.AfterFunc(d.window, func() { d.expire(k, s) })
+	d.seen[k] = s
+	d.mu.Unlock()
+
+	if evicted != nil {
+		evicted.flush(d.release)
+	}
+	return false
+}
+
+// remove forgets the window of the entries identical under k and returns it,
+// d.mu must be held.
+func (d *deduper) remove(k string) *seenEntry {
+	s := d.seen[k]
+	delete(d.seen, k)
+	d.lru.Remove(s.elem)
+	return s
+}
+
+// expire closes s, the window of the entries identical under k, unless it was
+// evicted meanwhile.
+func (d *deduper) expire(k string, s *seenEntry) {
+	d.mu.Lock()
+	if d.seen[k] != s {
+		d.mu.Unlock()
+		return
+	}
+	d.remove(k)
+	d.mu.Unlock()
+
+	s.flush(d.release)
+}
+
+// stop closes all the windows and stops suppressing entries.
+func (d *deduper) stop() {
+	d.mu.Lock()
+	seen := d.seen
+	d.seen = make(map[string]*seenEntry)
+	d.lru.Init()
+	d.stopped = true
+	d.mu.Unlock()
+
+	for _, s := range seen {
+		s.timer.Stop()
+		s.flush(d.release)
+	}
+}
+
+// flush releases the last suppressed entry with its RepeatCountField and
+// SuppressedCountField set, if any entry was suppressed.
+func (s *seenEntry) flush(release func(*log.Entry)) {
+	if s.count > 0 {
+		release(withFields(s.last, log.Fields{
+			RepeatCountField:     s.count + 1,
+			SuppressedCountField: s.count,
+		}))
+	}
+}
@@ -0,0 +1,114 @@
+package rollrus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestDedupe(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:     true,
+		LogLevels:    []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel},
+		DedupeWindow: 20 * time.Millisecond,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 5; i++ {
+		log.Error("hot loop")
+	}
+	if n := len(client.Items()); n != 1 {
+		t.Fatal("Expected the first entry to be reported right away, got items: ", n)
+	}
+	log.Error("once")
+	log.Warn("hot loop")
+
+	time.Sleep(50 * time.Millisecond)
+	log.Error("hot loop")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	var counts []string
+	for _, item := range client.Items() {
+		counts = append(counts, item.severity+":"+item.msg+":"+item.custom[RepeatCountField]+":"+item.custom[SuppressedCountField])
+	}
+	sort.Strings(counts)
+
+	expected := []string{"error:hot loop:5:4", "error:hot loop::", "error:hot loop::", "error:once::", "warning:hot loop::"}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("Expected %v, got %v", expected, counts)
+	}
+}
+
+func TestDedupeBounded(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:      true,
+		DedupeWindow:  time.Hour,
+		DedupeMaxKeys: 2,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for _, msg := range []string{"a", "b", "a", "c", "a", "c", "b"} {
+		log.Error(msg)
+	}
+
+	reported := func() []string {
+		var reported []string
+		for _, item := range client.Items() {
+			reported = append(reported, item.msg+":"+item.custom[SuppressedCountField])
+		}
+		return reported
+	}
+
+	// c evicts b, the least recently used, then b evicts a.
+	if expected := []string{"a:", "b:", "c:", "a:2", "b:"}; !reflect.DeepEqual(reported(), expected) {
+		t.Fatalf("Expected the least recently used windows to be closed, got %v", reported())
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+	if got := reported()[5:]; !reflect.DeepEqual(got, []string{"c:1"}) {
+		t.Fatalf("Expected the suppressed counts to be reported on Close, got %v", got)
+	}
+}
+
+func TestDedupeFingerprint(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:     true,
+		DedupeWindow: time.Hour,
+		FingerprintFunc: func(entry *logrus.Entry) string {
+			return fmt.Sprint(entry.Data["shard"])
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("shard", 1).Error("query failed")
+	log.WithField("shard", 2).Error("query failed")
+	log.WithField("shard", 1).Error("query failed")
+	log.WithFields(logrus.Fields{"shard": 1, FingerprintField: "db"}).Error("query failed")
+
+	if n := len(client.Items()); n != 3 {
+		t.Fatal("Expected entries with distinct fingerprints not to be deduplicated, got items: ", n)
+	}
+}
@@ -0,0 +1,36 @@
+package rollrus
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrBufferFull is passed to OnDrop for entries dropped because the
+	// buffer was full.
+	ErrBufferFull = errors.New("rollrus: buffer full")
+	// ErrShutdown is passed to OnDrop for entries dropped because Close
+	// timed out or was canceled before they were sent, or was called before
+	// FireAndForget.
+	ErrShutdown = errors.New("rollrus: shutdown before delivery")
+	// ErrCircuitOpen is passed to OnDrop for entries dropped because the
+	// circuit breaker was open, and returned by Fire for them when the hook
+	// is synchronous.
+	ErrCircuitOpen = errors.New("rollrus: circuit open")
+)
+
+// OnDropFunc is called with the entries that could not be delivered and the
+// reason why: ErrBufferFull, ErrShutdown, ErrCircuitOpen or the error of the
+// last delivery attempt. It is called from the goroutines of the pipeline, so it must be
+// fast, or hand the entry off to a goroutine of its own, and be safe for
+// concurrent use.
+type OnDropFunc func(entry *log.Entry, reason error)
+
+// dropped calls the OnDrop callback of the hook, if any.
+func (r *Hook) dropped(entry *log.Entry, reason error) {
+	r.debugLog.log("entry dropped", log.Fields{log.ErrorKey: reason, "message": entry.Message})
+	if r.onDrop != nil {
+		r.onDrop(entry, reason)
+	}
+}
@@ -0,0 +1,118 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/channel"
+	"github.com/sirupsen/logrus"
+)
+
+type drops struct {
+	mu      sync.Mutex
+	reasons []error
+}
+
+func (d *drops) record(entry *logrus.Entry, reason error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reasons = append(d.reasons, reason)
+}
+
+// wait waits for n drops and returns their reasons.
+func (d *drops) wait(t *testing.T, n int) []error {
+	deadline := time.Now().Add(time.Second)
+	for {
+		d.mu.Lock()
+		reasons := append([]error(nil), d.reasons...)
+		d.mu.Unlock()
+
+		if len(reasons) >= n || time.Now().After(deadline) {
+			return reasons
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOnDropBufferFull(t *testing.T) {
+	var d drops
+	hook := NewHookWithClient(&fakeClient{delay: 20 * time.Millisecond}, RollrusConfig{
+		Buffer:     channel.NewBufferWithPolicy(1, buffer.DropNewest),
+		NumWorkers: 1,
+		OnDrop:     d.record,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 5; i++ {
+		log.Error("overflowing")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	reasons := d.wait(t, 2)
+	if len(reasons) < 2 {
+		t.Fatalf("Expected at least 2 drops, got %d", len(reasons))
+	}
+	for _, reason := range reasons {
+		if reason != ErrBufferFull {
+			t.Fatal("Expected ErrBufferFull, got: ", reason)
+		}
+	}
+}
+
+func TestOnDropShutdown(t *testing.T) {
+	var d drops
+	hook := NewHookWithClient(&fakeClient{delay: 200 * time.Millisecond}, RollrusConfig{
+		NumWorkers: 1,
+		OnDrop:     d.record,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 3; i++ {
+		log.Error("slow error")
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if err := hook.CloseWithTimeout(10 * time.Millisecond); err == nil {
+		t.Fatal("Expected CloseWithTimeout to time out")
+	}
+
+	reasons := d.wait(t, 2)
+	if len(reasons) != 2 || reasons[0] != ErrShutdown || reasons[1] != ErrShutdown {
+		t.Fatalf("Expected 2 ErrShutdown drops, got %v", reasons)
+	}
+}
+
+func TestOnDropDeliveryFailure(t *testing.T) {
+	var d drops
+	failure := errors.New("unreachable")
+	hook := NewHookWithClient(&fakeClient{err: failure}, RollrusConfig{
+		SyncFire:    true,
+		ErrorLogger: ioutil.Discard,
+		OnDrop:      d.record,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("failing")
+
+	reasons := d.wait(t, 1)
+	if len(reasons) != 1 || !errors.Is(reasons[0], failure) {
+		t.Fatalf("Expected the delivery error, got %v", reasons)
+	}
+}
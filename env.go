@@ -0,0 +1,73 @@
+package rollrus
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The environment variables read by NewHookFromEnv.
+const (
+	// TokenEnvVar holds the Rollbar access token.
+	TokenEnvVar = "ROLLBAR_TOKEN"
+	// EnvironmentEnvVar holds the Rollbar environment.
+	EnvironmentEnvVar = "ROLLBAR_ENV"
+	// EnabledEnvVar disables reporting unless set to a true value, see
+	// EnabledFromEnv.
+	EnabledEnvVar = "ROLLBAR_ENABLED"
+)
+
+// EnabledFromEnv reports whether ROLLBAR_ENABLED is unset or set to a true
+// value: one understood by strconv.ParseBool, "yes" or "on". Any other value,
+// e.g. "off" or "disabled", turns reporting off. It is meant to be used as
+// RollrusConfig.Enabled.
+func EnabledFromEnv() bool {
+	v := os.Getenv(EnabledEnvVar)
+	if v == "" {
+		return true
+	}
+	switch strings.ToLower(v) {
+	case "yes", "on":
+		return true
+	}
+	enabled, err := strconv.ParseBool(v)
+	return err == nil && enabled
+}
+
+// NewHookFromEnv sets up a new hook with default reporting levels, reading the
+// token and environment from the ROLLBAR_TOKEN and ROLLBAR_ENV environment
+// variables. It returns an error when either is not set.
+func NewHookFromEnv() (*Hook, error) {
+	return NewHookFromEnvWithConfig(RollrusConfig{})
+}
+
+// NewHookFromEnvWithConfig works like NewHookFromEnv, but allows you to
+// configure the hook.
+func NewHookFromEnvWithConfig(config RollrusConfig) (*Hook, error) {
+	token := os.Getenv(TokenEnvVar)
+	if token == "" {
+		return nil, fmt.Errorf("rollrus: %s is not set", TokenEnvVar)
+	}
+
+	env := os.Getenv(EnvironmentEnvVar)
+	if env == "" {
+		return nil, fmt.Errorf("rollrus: %s is not set", EnvironmentEnvVar)
+	}
+
+	return NewHookForLevels(token, env, config), nil
+}
+
+// NewHookForEnvironments works like NewHookForLevels when env is one of
+// activeEnvs, e.g. "production" and "staging". Otherwise the returned hook
+// handles no levels and never reports anything, not even panics, and closing
+// it returns immediately.
+func NewHookForEnvironments(token string, env string, activeEnvs []string, config RollrusConfig) *Hook {
+	for _, e := range activeEnvs {
+		if e == env {
+			return NewHookForLevels(token, env, config)
+		}
+	}
+
+	return newInactiveHook()
+}
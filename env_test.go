@@ -0,0 +1,119 @@
+package rollrus
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewHookFromEnv(t *testing.T) {
+	for _, key := range []string{TokenEnvVar, EnvironmentEnvVar} {
+		if old, ok := os.LookupEnv(key); ok {
+			defer os.Setenv(key, old)
+		} else {
+			defer os.Unsetenv(key)
+		}
+	}
+
+	for _, test := range []struct {
+		token, env string
+		ok         bool
+	}{
+		{"some-long-token", "staging", true},
+		{"", "staging", false},
+		{"some-long-token", "", false},
+	} {
+		os.Setenv(TokenEnvVar, test.token)
+		os.Setenv(EnvironmentEnvVar, test.env)
+
+		hook, err := NewHookFromEnv()
+		if test.ok {
+			if err != nil {
+				t.Fatalf("Expected a hook for %+v, got: %v", test, err)
+			}
+			hook.Close()
+		} else if err == nil {
+			t.Fatalf("Expected an error for %+v", test)
+		}
+	}
+}
+
+func TestNewHookForEnvironments(t *testing.T) {
+	active := []string{"production", "staging"}
+
+	hook := NewHookForEnvironments("some-long-token", "staging", active, RollrusConfig{})
+	if len(hook.Levels()) == 0 {
+		t.Fatal("Expected the hook to be active in staging")
+	}
+	hook.Close()
+
+	hook = NewHookForEnvironments("some-long-token", "test", active, RollrusConfig{})
+	if levels := hook.Levels(); len(levels) != 0 {
+		t.Fatal("Expected the hook to handle no levels in test, got: ", levels)
+	}
+
+	if err := hook.Fire(logrus.NewEntry(logrus.New())); err != nil {
+		t.Fatal("Expected the inactive hook to ignore entries, got: ", err)
+	}
+
+	start := time.Now()
+	if err := hook.Close(); err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Fatal("Expected the inactive hook to close immediately, got: ", err)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	defer os.Unsetenv(EnabledEnvVar)
+
+	os.Setenv(EnabledEnvVar, "false")
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{Enabled: EnabledFromEnv})
+
+	if levels := hook.Levels(); len(levels) != 0 {
+		t.Fatal("Expected the disabled hook to handle no levels, got: ", levels)
+	}
+	if n := hook.WorkerCount(); n != 0 {
+		t.Fatalf("Expected the disabled hook to run no workers, got %d", n)
+	}
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	if err := hook.Fire(entry); err != nil {
+		t.Fatal("Expected the disabled hook to ignore entries, got: ", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+	if n := len(client.Items()); n != 0 {
+		t.Fatalf("Expected nothing to be reported, got %d items", n)
+	}
+
+	os.Unsetenv(EnabledEnvVar)
+	if !EnabledFromEnv() {
+		t.Fatal("Expected reporting to be enabled without ROLLBAR_ENABLED")
+	}
+}
+
+func TestEnabledFromEnvValues(t *testing.T) {
+	defer os.Unsetenv(EnabledEnvVar)
+
+	for v, expected := range map[string]bool{
+		"1":        true,
+		"true":     true,
+		"TRUE":     true,
+		"yes":      true,
+		"On":       true,
+		"0":        false,
+		"false":    false,
+		"no":       false,
+		"off":      false,
+		"disabled": false,
+	} {
+		os.Setenv(EnabledEnvVar, v)
+		if enabled := EnabledFromEnv(); enabled != expected {
+			t.Errorf("Expected ROLLBAR_ENABLED=%s to give %v, got %v", v, expected, enabled)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package rollrus
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// errorLine is a JSON structured line written to the ErrorLogger.
+type errorLine struct {
+	Level   string `json:"level"`
+	Hook    string `json:"hook"`
+	Msg     string `json:"msg"`
+	Err     string `json:"err"`
+	Attempt int    `json:"attempt,omitempty"`
+}
+
+// errorLogger writes the errors the hook runs into as JSON lines, and to the
+// debugLogger when there is one.
+type errorLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	debug *debugLogger
+}
+
+func newErrorLogger(w io.Writer, debug *debugLogger) *errorLogger {
+	return &errorLogger{w: w, debug: debug}
+}
+
+// log writes msg and err as a single JSON line. A nil errorLogger, or one
+// without a writer, writes to os.Stderr.
+func (l *errorLogger) log(msg string, err error, attempt int) {
+	if l != nil {
+		l.debug.log(msg, log.Fields{log.ErrorKey: err, "attempt": attempt})
+	}
+
+	line, jsonErr := json.Marshal(errorLine{
+		Level:   "error",
+		Hook:    "rollbar",
+		Msg:     msg,
+		Err:     err.Error(),
+		Attempt: attempt,
+	})
+	if jsonErr != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	if l == nil || l.w == nil {
+		os.Stderr.Write(line)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(line)
+}
@@ -0,0 +1,48 @@
+package rollrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestErrorLogger(t *testing.T) {
+	var out bytes.Buffer
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers:  1,
+		ErrorLogger: &out,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("undeliverable")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", out.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"level":   "error",
+		"hook":    "rollbar",
+		"msg":     "delivery failed",
+		"err":     "unreachable",
+		"attempt": float64(1),
+	}
+	for k, v := range expected {
+		if line[k] != v {
+			t.Fatalf("Expected %s to equal %v, but instead it is: %v", k, v, line[k])
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package rollrus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+// stackTracer is implemented by errors created or wrapped by
+// github.com/pkg/errors.
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// setError reports err under k along with its concrete type, the chain of
+// errors it wraps and, for pkg/errors errors, the stack trace:
+//
+//	k             the error message
+//	k.type        the concrete type of err
+//	k.cause       the message of the innermost wrapped error
+//	k.cause.type  the concrete type of the innermost wrapped error
+//	k.chain       the types of every error in the chain, outermost first
+//	k.stack       the stack trace of the innermost error that recorded one
+func setError(m map[string]string, k string, err error) {
+	m[k] = err.Error()
+	m[k+".type"] = fmt.Sprintf("%T", err)
+
+	var chain []string
+	cause := err
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, fmt.Sprintf("%T", e))
+		cause = e
+	}
+
+	if cause != err {
+		m[k+".cause"] = cause.Error()
+		m[k+".cause.type"] = fmt.Sprintf("%T", cause)
+		m[k+".chain"] = strings.Join(chain, " > ")
+	}
+
+	if stack := innermostStack(err); stack != nil {
+		m[k+".stack"] = strings.TrimSpace(fmt.Sprintf("%+v", stack.StackTrace()))
+	}
+}
+
+// innermostStack returns the innermost error of the chain wrapped by err that
+// recorded a stack trace, which is the closest to where the error originated.
+func innermostStack(err error) stackTracer {
+	var stack stackTracer
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if st, ok := e.(stackTracer); ok {
+			stack = st
+		}
+	}
+	return stack
+}
+
+// stackPCs returns the stack trace recorded by st as program counters.
+func stackPCs(st stackTracer) []uintptr {
+	trace := st.StackTrace()
+	pcs := make([]uintptr, len(trace))
+	for i, f := range trace {
+		pcs[i] = uintptr(f)
+	}
+	return pcs
+}
+
+// stackedErrors returns the errors of the chain wrapped by err that recorded
+// a stack trace, outermost first.
+func stackedErrors(err error) []error {
+	var chain []error
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if _, ok := e.(stackTracer); ok {
+			chain = append(chain, e)
+		}
+	}
+	return chain
+}
+
+// setTraceChain reports every error of the chain wrapped by err that recorded
+// a stack trace, outermost first, as Rollbar's trace chain would:
+//
+//	k.trace_chain.N       the error message
+//	k.trace_chain.N.type  the concrete type of the error
+//	k.trace_chain.N.stack the stack trace recorded by the error
+//
+// roll.Client can only send a single trace, hence the custom fields. Items
+// built by the hook carry the chain of the error field as their trace chain
+// instead, see job.traceChain.
+func setTraceChain(m map[string]string, k string, err error) {
+	for n, e := range stackedErrors(err) {
+		prefix := fmt.Sprintf("%s.trace_chain.%d", k, n)
+		m[prefix] = e.Error()
+		m[prefix+".type"] = fmt.Sprintf("%T", e)
+		m[prefix+".stack"] = strings.TrimSpace(fmt.Sprintf("%+v", e.(stackTracer).StackTrace()))
+	}
+}
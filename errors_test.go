@@ -0,0 +1,85 @@
+package rollrus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestErrorChainConversion(t *testing.T) {
+	cause := &os.PathError{Op: "open", Path: "/missing", Err: os.ErrNotExist}
+	err := fmt.Errorf("loading config: %w", cause)
+
+	r := convertFields(logrus.Fields{"error": err})
+
+	expected := map[string]string{
+		"error":            "loading config: open /missing: file does not exist",
+		"error.type":       "*fmt.wrapError",
+		"error.cause":      "file does not exist",
+		"error.cause.type": "*errors.errorString",
+		"error.chain":      "*fmt.wrapError > *fs.PathError > *errors.errorString",
+	}
+	for k, v := range expected {
+		if r[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, r[k])
+		}
+	}
+
+	if _, ok := r["error.stack"]; ok {
+		t.Fatal("Expected no stack for errors without one")
+	}
+}
+
+func TestErrorStackConversion(t *testing.T) {
+	err := pkgerrors.Wrap(pkgerrors.New("boom"), "doing work")
+
+	r := convertFields(logrus.Fields{"error": err})
+
+	if v := r["error"]; v != "doing work: boom" {
+		t.Fatal("Expected error message without the stack, but instead it is: ", v)
+	}
+
+	if v := r["error.stack"]; !strings.Contains(v, "TestErrorStackConversion") {
+		t.Fatal("Expected the stack trace of the error, but instead it is: ", v)
+	}
+}
+
+func TestWrapCauses(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true, WrapCauses: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	err := pkgerrors.New("disk full")
+	err = pkgerrors.Wrap(err, "writing block")
+	err = pkgerrors.Wrap(err, "saving file")
+	log.WithError(err).Error("with causes")
+
+	custom := client.Items()[0].custom
+	expected := []string{
+		"saving file: writing block: disk full",
+		"writing block: disk full",
+		"disk full",
+	}
+	for i, msg := range expected {
+		prefix := fmt.Sprintf("error.trace_chain.%d", i)
+		if custom[prefix] != msg {
+			t.Errorf("Expected %s to be %q, got %q", prefix, msg, custom[prefix])
+		}
+		if !strings.Contains(custom[prefix+".stack"], "TestWrapCauses") {
+			t.Errorf("Expected %s.stack to hold a stack trace, got %q", prefix, custom[prefix+".stack"])
+		}
+	}
+
+	if _, ok := custom["error.trace_chain.3"]; ok {
+		t.Error("Expected 3 traces only")
+	}
+}
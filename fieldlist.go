@@ -0,0 +1,54 @@
+package rollrus
+
+import (
+	"path"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// fieldList keeps the fields of an entry matching the whitelist or, when it's
+// empty, the ones not matching the blacklist. Both hold path.Match patterns.
+type fieldList struct {
+	whitelist []string
+	blacklist []string
+}
+
+func newFieldList(whitelist, blacklist []string) *fieldList {
+	if len(whitelist) == 0 && len(blacklist) == 0 {
+		return nil
+	}
+	return &fieldList{whitelist: whitelist, blacklist: blacklist}
+}
+
+func matchAny(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keep reports whether the field key is reported.
+func (l *fieldList) keep(key string) bool {
+	if len(l.whitelist) > 0 {
+		return matchAny(l.whitelist, key)
+	}
+	return !matchAny(l.blacklist, key)
+}
+
+// filter returns a copy of fields without the fields that aren't reported. A
+// nil fieldList returns fields as is.
+func (l *fieldList) filter(fields log.Fields) log.Fields {
+	if l == nil {
+		return fields
+	}
+
+	filtered := make(log.Fields, len(fields))
+	for k, v := range fields {
+		if l.keep(k) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,67 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFieldBlacklist(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:       true,
+		FieldBlacklist: []string{"ssn", "*_token"},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"ssn":          "123-45-6789",
+		"github_token": "abc",
+		"user":         "bob",
+	}).Error("blacklisted")
+
+	custom := client.Items()[0].custom
+	for _, k := range []string{"ssn", "github_token"} {
+		if _, ok := custom[k]; ok {
+			t.Errorf("Expected %s not to be reported", k)
+		}
+	}
+
+	if custom["user"] != "bob" {
+		t.Errorf("Expected user to be reported, got %q", custom["user"])
+	}
+}
+
+func TestFieldWhitelist(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:       true,
+		FieldWhitelist: []string{"user", "request_*"},
+		FieldBlacklist: []string{"user"},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"user":       "bob",
+		"request_id": "42",
+		"card":       "4111111111111111",
+	}).Error("whitelisted")
+
+	custom := client.Items()[0].custom
+	if _, ok := custom["card"]; ok {
+		t.Error("Expected card not to be reported")
+	}
+
+	if custom["user"] != "bob" || custom["request_id"] != "42" {
+		t.Errorf("Expected the whitelisted fields to be reported, got %v", custom)
+	}
+}
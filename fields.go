@@ -0,0 +1,40 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// WithFields returns a hook that adds fields to every entry it fires, on top
+// of the fields of r. Fields set on an entry take precedence. The returned
+// hook shares the buffer and workers of r, so closing or flushing either of
+// them closes or flushes both.
+func (r *Hook) WithFields(fields log.Fields) *Hook {
+	h := *r
+	h.fields = make(log.Fields, len(r.fields)+len(fields))
+	for k, v := range r.fields {
+		h.fields[k] = v
+	}
+	for k, v := range fields {
+		h.fields[k] = v
+	}
+	return &h
+}
+
+// withFields returns a copy of entry with fields added to its data.
+func withFields(entry *log.Entry, fields log.Fields) *log.Entry {
+	if len(fields) == 0 {
+		return entry
+	}
+
+	data := make(log.Fields, len(fields)+len(entry.Data))
+	for k, v := range fields {
+		data[k] = v
+	}
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+
+	e := *entry
+	e.Data = data
+	return &e
+}
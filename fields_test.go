@@ -0,0 +1,79 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithFields(t *testing.T) {
+	client := &fakeClient{}
+	base := NewHookWithClient(client, RollrusConfig{})
+	hook := base.WithFields(logrus.Fields{"region": "us-east-1", "cluster": "a"})
+	hook = hook.WithFields(logrus.Fields{"cluster": "b"})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("region", "eu-west-1").Error("with fields")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	if got := items[0].custom["region"]; got != "eu-west-1" {
+		t.Errorf("Expected the entry field to take precedence, got %q", got)
+	}
+
+	if got := items[0].custom["cluster"]; got != "b" {
+		t.Errorf("Expected cluster=b, got %q", got)
+	}
+
+	if len(base.fields) != 0 {
+		t.Errorf("Expected the wrapped hook to be unchanged, got %v", base.fields)
+	}
+
+	if err := base.Close(); err != nil {
+		t.Fatal("Expected closing the wrapped hook again to succeed, got: ", err)
+	}
+}
+
+func TestDefaultFields(t *testing.T) {
+	defaults := map[string]string{"service": "api", "region": "us-east-1"}
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:      true,
+		DefaultFields: defaults,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("region", "eu-west-1").Error("with defaults")
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	if got := items[0].custom["service"]; got != "api" {
+		t.Errorf("Expected service=api, got %q", got)
+	}
+
+	if got := items[0].custom["region"]; got != "eu-west-1" {
+		t.Errorf("Expected the entry field to take precedence, got %q", got)
+	}
+
+	if got := defaults["region"]; got != "us-east-1" {
+		t.Errorf("Expected DefaultFields to be unchanged, got region=%q", got)
+	}
+}
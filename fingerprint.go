@@ -0,0 +1,44 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// FingerprintField is the entry field setting the fingerprint of the item.
+// Rollbar groups the items built by the hook by it, see
+// RollrusConfig.HTTPClient; roll only reports it as a custom field.
+const FingerprintField = "rollbar_fingerprint"
+
+// FingerprintFunc returns the fingerprint of the item reported for entry, see
+// FingerprintField. An empty fingerprint leaves the grouping to Rollbar.
+type FingerprintFunc func(entry *log.Entry) string
+
+// setFingerprint moves the FingerprintField of m to the fingerprint key, or
+// sets it from fn when the field is missing.
+func setFingerprint(m map[string]string, fn FingerprintFunc, entry *log.Entry) {
+	if v, ok := m[FingerprintField]; ok {
+		delete(m, FingerprintField)
+		if v != "" {
+			m["fingerprint"] = v
+			return
+		}
+	}
+
+	if fn == nil {
+		return
+	}
+	if v := callFingerprint(fn, entry); v != "" {
+		m["fingerprint"] = v
+	}
+}
+
+// callFingerprint returns fn(entry), or an empty fingerprint when fn panics so
+// that the item is still sent.
+func callFingerprint(fn FingerprintFunc, entry *log.Entry) (v string) {
+	defer func() {
+		if recover() != nil {
+			v = ""
+		}
+	}()
+	return fn(entry)
+}
@@ -0,0 +1,69 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFingerprint(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire: true,
+		FingerprintFunc: func(entry *logrus.Entry) string {
+			return "func-" + entry.Message
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField(FingerprintField, "db-timeout").Error("field")
+	log.Error("func")
+
+	items := client.Items()
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+
+	if got := items[0].custom["fingerprint"]; got != "db-timeout" {
+		t.Errorf("Expected the field fingerprint, got %q", got)
+	}
+
+	if _, ok := items[0].custom[FingerprintField]; ok {
+		t.Errorf("Expected %s to be reported as fingerprint only", FingerprintField)
+	}
+
+	if got := items[1].custom["fingerprint"]; got != "func-func" {
+		t.Errorf("Expected the FingerprintFunc fingerprint, got %q", got)
+	}
+}
+
+func TestFingerprintPanic(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire: true,
+		FingerprintFunc: func(entry *logrus.Entry) string {
+			return entry.Data["constraint"].(string)
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("without constraint")
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected the item to be sent despite the panic, got %d items", len(items))
+	}
+
+	if _, ok := items[0].custom["fingerprint"]; ok {
+		t.Error("Expected no fingerprint when FingerprintFunc panics")
+	}
+}
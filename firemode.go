@@ -0,0 +1,91 @@
+package rollrus
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FireMode is how Fire hands entries over, see RollrusConfig.FireMode.
+type FireMode int
+
+const (
+	// FireModeAsync buffers entries for the workers. Fire returns once the
+	// entry is buffered, which blocks while the buffer is full unless its
+	// BufferOverflowPolicy drops entries.
+	FireModeAsync FireMode = iota
+	// FireModeSync sends entries before Fire returns, like SyncFire.
+	FireModeSync
+	// FireModeFireAndForget makes Fire work like FireAndForget: it never
+	// waits for Rollbar nor for room in the buffer, and never returns an
+	// error. The default buffer then drops the newest entries when full.
+	FireModeFireAndForget
+)
+
+// FireAndForget reports entry without waiting for it to be sent, whatever the
+// FireMode of the hook, for latency sensitive code paths. Delivery errors are
+// only passed to OnDrop, so it always returns nil. With FireModeSync the entry
+// is sent on a goroutine of its own, which Close waits for. Up to NumWorkers
+// entries are sent at once, the others are dropped with ErrBufferFull, and
+// entries fired once Close was called are dropped with ErrShutdown.
+func (r *Hook) FireAndForget(entry *log.Entry) error {
+	if !r.sync {
+		r.fire(entry)
+		return nil
+	}
+
+	// Record the stack of the caller rather than of the goroutine.
+	if r.stacks {
+		entry = withStack(entry)
+	}
+
+	if err := r.forgotten.start(r.wg); err != nil {
+		r.counters.reject()
+		r.dropped(entry, err)
+		return nil
+	}
+	go func() {
+		defer r.forgotten.done(r.wg)
+		r.fire(entry)
+	}()
+	return nil
+}
+
+// forgotten tracks the goroutines FireAndForget starts for a synchronous hook.
+type forgotten struct {
+	mu      sync.Mutex
+	running int
+	max     int
+	closed  bool
+}
+
+// start counts a new goroutine in wg, or returns why it can't be started.
+// Adding to wg under the lock keeps it from racing with the wg.Wait of Close.
+func (f *forgotten) start(wg *sync.WaitGroup) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrShutdown
+	}
+	if f.running >= f.max {
+		return ErrBufferFull
+	}
+	f.running++
+	wg.Add(1)
+	return nil
+}
+
+func (f *forgotten) done(wg *sync.WaitGroup) {
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+	wg.Done()
+}
+
+// close stops new goroutines from being started.
+func (f *forgotten) close() {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+}
@@ -0,0 +1,110 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFireModeSync(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{FireMode: FireModeSync})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("sent before returning")
+
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected the entry to be sent before Fire returned, got %d items", n)
+	}
+}
+
+func TestFireAndForget(t *testing.T) {
+	client := &fakeClient{delay: 20 * time.Millisecond, err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true, ErrorLogger: ioutil.Discard})
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "not waited for"
+
+	if err := hook.FireAndForget(entry); err != nil {
+		t.Fatal("Expected FireAndForget not to return delivery errors, got: ", err)
+	}
+	if n := len(client.Items()); n != 0 {
+		t.Fatal("Expected FireAndForget not to wait for the entry to be sent")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected Close to wait for the entry to be sent, got %d items", n)
+	}
+}
+
+func TestFireModeFireAndForget(t *testing.T) {
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:    true,
+		FireMode:    FireModeFireAndForget,
+		ErrorLogger: ioutil.Discard,
+	})
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	if err := hook.Fire(entry); err != nil {
+		t.Fatal("Expected Fire not to return delivery errors, got: ", err)
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+	if stats := hook.Stats(); stats.Failed != 1 {
+		t.Fatalf("Expected the failed delivery to be counted, got %+v", stats)
+	}
+}
+
+func TestFireAndForgetBounded(t *testing.T) {
+	var mu sync.Mutex
+	var reasons []error
+	client := &fakeClient{delay: 20 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:   true,
+		NumWorkers: 2,
+		OnDrop: func(entry *logrus.Entry, reason error) {
+			mu.Lock()
+			reasons = append(reasons, reason)
+			mu.Unlock()
+		},
+	})
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "not waited for"
+
+	for i := 0; i < 3; i++ {
+		hook.FireAndForget(entry)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+	hook.FireAndForget(entry)
+
+	if n := len(client.Items()); n != 2 {
+		t.Fatalf("Expected NumWorkers entries to be sent at once, got %d items", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(reasons, []error{ErrBufferFull, ErrShutdown}) {
+		t.Fatal("Expected the entries over the bound and after Close to be dropped, got: ", reasons)
+	}
+}
@@ -0,0 +1,88 @@
+package rollrus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// flattenFields works like convertFields, but reports nested maps, structs
+// and slices up to depth levels deep as dotted keys. A depth of 0 is the same
+// as convertFields.
+func flattenFields(fields log.Fields, depth int) map[string]string {
+	if depth <= 0 {
+		return convertFields(fields)
+	}
+
+	m := make(map[string]string)
+	for k, v := range fields {
+		flattenValue(m, k, v, depth)
+	}
+
+	return m
+}
+
+func flattenValue(m map[string]string, key string, v interface{}, depth int) {
+	if depth <= 0 || !flattenable(v) {
+		setField(m, key, v)
+		return
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+
+	n := len(m)
+	switch rv.Kind() {
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			flattenValue(m, key+"."+fmt.Sprint(k.Interface()), rv.MapIndex(k).Interface(), depth-1)
+		}
+	case reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				flattenValue(m, key+"."+f.Name, rv.Field(i).Interface(), depth-1)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			flattenValue(m, key+"."+strconv.Itoa(i), rv.Index(i).Interface(), depth-1)
+		}
+	}
+
+	// Structs without exported fields have no keys of their own.
+	if len(m) == n {
+		setField(m, key, v)
+	}
+}
+
+// flattenable reports whether v is a non empty map, struct or slice that
+// doesn't know how to format itself.
+func flattenable(v interface{}) bool {
+	switch v.(type) {
+	case time.Time, fmt.Stringer, error, []byte, json.RawMessage:
+		return false
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Struct:
+		return true
+	}
+	return false
+}
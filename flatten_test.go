@@ -0,0 +1,70 @@
+package rollrus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type flattenUser struct {
+	ID    int
+	Email string
+	Tags  []string
+	admin bool
+}
+
+func TestFlattenFields(t *testing.T) {
+	fields := logrus.Fields{
+		"user":  &flattenUser{ID: 1, Email: "bob@example.com", Tags: []string{"a", "b"}},
+		"items": map[string]interface{}{"count": 2},
+		"plain": "value",
+	}
+
+	expected := map[string]string{
+		"user.ID":     "1",
+		"user.Email":  "bob@example.com",
+		"user.Tags.0": "a",
+		"user.Tags.1": "b",
+		"items.count": "2",
+		"plain":       "value",
+	}
+
+	if r := flattenFields(fields, 5); !reflect.DeepEqual(r, expected) {
+		t.Fatalf("Expected %v, got %v", expected, r)
+	}
+}
+
+func TestFlattenFieldsDepth(t *testing.T) {
+	fields := logrus.Fields{
+		"user": flattenUser{ID: 1, Tags: []string{"a"}},
+	}
+
+	r := flattenFields(fields, 1)
+	if v := r["user.Tags"]; v != "[a]" {
+		t.Fatal("Expected Tags to be formatted past the max depth, but instead it is: ", v)
+	}
+
+	r = flattenFields(fields, 0)
+	if _, ok := r["user"]; !ok || len(r) != 1 {
+		t.Fatal("Expected fields not to be flattened with a depth of 0, got: ", r)
+	}
+}
+
+func TestFlattenUnexportedStruct(t *testing.T) {
+	type opaque struct{ id int }
+
+	fields := logrus.Fields{
+		"empty":  struct{}{},
+		"opaque": opaque{id: 1},
+	}
+
+	expected := map[string]string{
+		"empty":  "{}",
+		"opaque": "{id:1}",
+	}
+
+	if r := flattenFields(fields, 5); !reflect.DeepEqual(r, expected) {
+		t.Fatalf("Expected %v, got %v", expected, r)
+	}
+}
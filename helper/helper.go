@@ -0,0 +1,58 @@
+// Package helper logs and reports an entry to Rollbar in a single call, with
+// the trace metadata of its context.
+package helper
+
+import (
+	"context"
+	"time"
+
+	"github.com/benjamindow/rollrus"
+	log "github.com/sirupsen/logrus"
+)
+
+// Critical logs msg with fields at the error level with the standard logger,
+// and reports it through h with the critical severity. The fatal and panic
+// levels are avoided as logging at them exits or panics.
+func Critical(ctx context.Context, h *rollrus.Hook, msg string, fields log.Fields) {
+	report(ctx, h, log.ErrorLevel, log.PanicLevel, msg, fields)
+}
+
+// Error logs msg with fields at the error level with the standard logger,
+// and reports it through h.
+func Error(ctx context.Context, h *rollrus.Hook, msg string, fields log.Fields) {
+	report(ctx, h, log.ErrorLevel, log.ErrorLevel, msg, fields)
+}
+
+// Warning logs msg with fields at the warning level with the standard logger,
+// and reports it through h.
+func Warning(ctx context.Context, h *rollrus.Hook, msg string, fields log.Fields) {
+	report(ctx, h, log.WarnLevel, log.WarnLevel, msg, fields)
+}
+
+// Info logs msg with fields at the info level with the standard logger, and
+// reports it through h.
+func Info(ctx context.Context, h *rollrus.Hook, msg string, fields log.Fields) {
+	report(ctx, h, log.InfoLevel, log.InfoLevel, msg, fields)
+}
+
+// report logs msg at level and fires it on h at reportLevel with the trace
+// metadata of ctx, whether or not h handles the level. The logged entry is
+// skipped by the hooks of the logger, h included, so that it isn't reported
+// twice.
+func report(ctx context.Context, h *rollrus.Hook, level, reportLevel log.Level, msg string, fields log.Fields) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	log.WithContext(rollrus.WithoutReport(ctx)).WithFields(fields).Log(level, msg)
+
+	entry := log.WithContext(ctx)
+	for k, v := range rollrus.TraceFields(ctx) {
+		entry = entry.WithField(k, v)
+	}
+	entry = entry.WithFields(fields)
+	entry.Time = time.Now()
+	entry.Level = reportLevel
+	entry.Message = msg
+	h.Fire(entry)
+}
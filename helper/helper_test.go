@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/benjamindow/rollrus"
+	"github.com/sirupsen/logrus"
+)
+
+func TestError(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	defer logrus.SetOutput(ioutil.Discard)
+
+	hook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	defer hook.Close()
+	logrus.AddHook(hook)
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := rollrus.WithRequest(context.Background(), req)
+
+	Error(ctx, hook.Hook, "failed", logrus.Fields{"order_id": 42})
+
+	if !strings.Contains(out.String(), "failed") {
+		t.Fatal("Expected the entry to be logged, got: ", out.String())
+	}
+
+	entries := hook.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected the entry to be reported once, got %d items", len(entries))
+	}
+	if entries[0].Fields["order_id"] != "42" || entries[0].Fields["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("Expected the fields and the trace metadata to be reported, got %v", entries[0].Fields)
+	}
+}
+
+func TestCritical(t *testing.T) {
+	logrus.SetOutput(ioutil.Discard)
+
+	hook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	Critical(context.Background(), hook.Hook, "on fire", nil)
+
+	entries := hook.Entries()
+	if len(entries) != 1 || entries[0].Level != rollrus.SeverityCritical {
+		t.Fatalf("Expected a critical item, got %+v", entries)
+	}
+}
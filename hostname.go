@@ -0,0 +1,47 @@
+package rollrus
+
+import (
+	"os"
+	"strconv"
+)
+
+// The fields the host of the process is reported under, unless
+// RollrusConfig.DisableHostInfo is set. Fields set on an entry or in
+// RollrusConfig.DefaultFields take precedence.
+const (
+	HostField = "host"
+	PIDField  = "pid"
+)
+
+// setHostInfo sets the hostname and process ID in m.
+func setHostInfo(m map[string]string) {
+	if hostname, err := os.Hostname(); err == nil {
+		m[HostField] = hostname
+	}
+	m[PIDField] = strconv.Itoa(os.Getpid())
+}
+
+// HostnameKey is the custom field the hostname of the item is reported under.
+// roll always reports os.Hostname() as server.host, so the hostname set with
+// RollrusConfig.Hostname or HostnameFromField is only reported as the
+// server.host of the item when the hook sends the items itself, see
+// RollrusConfig.HTTPClient.
+const HostnameKey = "server.host"
+
+// setHostname reports the hostname of the item under HostnameKey: hostname
+// when set, otherwise the field of m, which is removed, when set. Without
+// either the hostname is left to the client, i.e. os.Hostname().
+func setHostname(m map[string]string, hostname, field string) {
+	if field != "" {
+		if v, ok := m[field]; ok {
+			delete(m, field)
+			if hostname == "" {
+				hostname = v
+			}
+		}
+	}
+
+	if hostname != "" {
+		m[HostnameKey] = hostname
+	}
+}
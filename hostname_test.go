@@ -0,0 +1,75 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHostname(t *testing.T) {
+	for _, test := range []struct {
+		hostname string
+		field    string
+		expected string
+	}{
+		{"explicit", "node", "explicit"},
+		{"", "node", "node-1"},
+		{"", "", ""},
+	} {
+		client := &fakeClient{}
+		hook := NewHookWithClient(client, RollrusConfig{
+			SyncFire:          true,
+			Hostname:          test.hostname,
+			HostnameFromField: test.field,
+		})
+
+		log := logrus.New()
+		log.Out = ioutil.Discard
+		log.Hooks.Add(hook)
+
+		log.WithField("node", "node-1").Error("hostname")
+		hook.Close()
+
+		custom := client.Items()[0].custom
+		if custom[HostnameKey] != test.expected {
+			t.Fatalf("Expected the hostname %q, got %q", test.expected, custom[HostnameKey])
+		}
+
+		if _, ok := custom["node"]; ok == (test.field != "") {
+			t.Fatalf("Expected the hostname field to be removed only when used, got %v", custom)
+		}
+	}
+}
+
+func TestHostInfo(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("with host info")
+	log.WithField(HostField, "web-1").Error("with an explicit host")
+
+	hostname, _ := os.Hostname()
+	items := client.Items()
+	if items[0].custom[HostField] != hostname || items[0].custom[PIDField] != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("Expected the host and pid to be reported, got %v", items[0].custom)
+	}
+	if items[1].custom[HostField] != "web-1" {
+		t.Fatal("Expected the entry's host field to win, got: ", items[1].custom[HostField])
+	}
+
+	disabled := NewHookWithClient(client, RollrusConfig{SyncFire: true, DisableHostInfo: true})
+	defer disabled.Close()
+	disabled.Fire(logrus.NewEntry(log))
+
+	if _, ok := client.Items()[2].custom[PIDField]; ok {
+		t.Fatal("Expected DisableHostInfo to stop the pid from being reported")
+	}
+}
@@ -0,0 +1,335 @@
+package rollrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/adler32"
+	"hash/crc32"
+	"net/http"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/stvp/roll"
+)
+
+// TransformFunc is called with every item, the data of the payload sent to
+// Rollbar's item API, and returns the item to send. It may change or add any
+// key, e.g. server.host or notifier.name.
+type TransformFunc func(item map[string]interface{}) map[string]interface{}
+
+// httpClient is a RollClient sending items through a configurable
+// http.Client, see RollrusConfig.HTTPClient. roll.New always sends through
+// http.DefaultClient. The items follow roll's: the same exception class and,
+// unless the entry sets one, a fingerprint hashed from the frames. Unlike
+// roll's, they also report the promoted fields, e.g. the person, and the
+// notifier. Items are not guaranteed to group with those sent through roll.
+type httpClient struct {
+	token    string
+	env      string
+	endpoint string
+	client   *http.Client
+	// root is the server.root of the items, omitted when empty.
+	root string
+	// transform can be nil.
+	transform TransformFunc
+}
+
+// newRollClient returns the client reporting to the project of token: a
+// roll.Client, or an httpClient when the config sets its HTTPClient,
+// Transformer, Endpoint or ServerRoot.
+func newRollClient(token, env string, config RollrusConfig) RollClient {
+	if config.HTTPClient == nil && config.Transformer == nil && config.Endpoint == "" && config.ServerRoot == "" {
+		return roll.New(token, env)
+	}
+
+	c := &httpClient{
+		token:     token,
+		env:       env,
+		endpoint:  config.Endpoint,
+		client:    config.HTTPClient,
+		root:      config.ServerRoot,
+		transform: config.Transformer,
+	}
+	if c.endpoint == "" {
+		c.endpoint = roll.DefaultEndpoint
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	return c
+}
+
+func (c *httpClient) Critical(err error, custom map[string]string) (string, error) {
+	return c.CriticalStack(err, callers(), custom)
+}
+
+func (c *httpClient) CriticalStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return c.send(c.traceItem(SeverityCritical, err, ptrs, interfaceMap(custom)))
+}
+
+func (c *httpClient) Error(err error, custom map[string]string) (string, error) {
+	return c.ErrorStack(err, callers(), custom)
+}
+
+func (c *httpClient) ErrorStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return c.send(c.traceItem(SeverityError, err, ptrs, interfaceMap(custom)))
+}
+
+func (c *httpClient) Warning(err error, custom map[string]string) (string, error) {
+	return c.WarningStack(err, callers(), custom)
+}
+
+func (c *httpClient) WarningStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return c.send(c.traceItem(SeverityWarning, err, ptrs, interfaceMap(custom)))
+}
+
+func (c *httpClient) Info(msg string, custom map[string]string) (string, error) {
+	return c.send(c.messageItem(SeverityInfo, msg, interfaceMap(custom)))
+}
+
+func (c *httpClient) Debug(msg string, custom map[string]string) (string, error) {
+	return c.send(c.messageItem(SeverityDebug, msg, interfaceMap(custom)))
+}
+
+// report sends err at level with the custom data of any type, which the
+// roll.Client methods only take as strings, see RollrusConfig.RichFields.
+// Unless chain is empty, the item reports its errors as its trace chain
+// rather than err and ptrs, see RollrusConfig.WrapCauses.
+func (c *httpClient) report(level string, err error, ptrs []uintptr, custom map[string]interface{}, chain []error) (string, error) {
+	switch level {
+	case SeverityCritical, SeverityError, SeverityWarning:
+		if len(chain) > 0 {
+			return c.send(c.traceChainItem(level, err, chain, custom))
+		}
+		if len(ptrs) == 0 {
+			ptrs = callers()
+		}
+		return c.send(c.traceItem(level, err, ptrs, custom))
+	case SeverityInfo, SeverityDebug:
+		return c.send(c.messageItem(level, err.Error(), custom))
+	}
+	return "", fmt.Errorf("Unknown severity %q", level)
+}
+
+func interfaceMap(m map[string]string) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		converted[k] = v
+	}
+	return converted
+}
+
+// callers returns the stack of the caller of the client method.
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+// item returns the common part of the items reported to Rollbar.
+func (c *httpClient) item(level, title string, custom map[string]interface{}) map[string]interface{} {
+	hostname, ok := custom[HostnameKey].(string)
+	if ok {
+		delete(custom, HostnameKey)
+	} else {
+		hostname, _ = os.Hostname()
+	}
+
+	server := map[string]interface{}{"host": hostname}
+	if c.root != "" {
+		server["root"] = c.root
+	}
+
+	item := map[string]interface{}{
+		"environment": c.env,
+		"title":       title,
+		"level":       level,
+		"timestamp":   time.Now().Unix(),
+		"platform":    runtime.GOOS,
+		"language":    "go",
+		"server":      server,
+		"notifier":    map[string]interface{}{"name": "rollrus"},
+		"custom":      custom,
+	}
+
+	// roll can only report these as custom fields, see
+	// RollrusConfig.CodeVersion and FingerprintField.
+	for _, k := range []string{"code_version", "fingerprint"} {
+		if v, ok := custom[k]; ok {
+			delete(custom, k)
+			item[k] = v
+		}
+	}
+	person := map[string]interface{}{}
+	for _, k := range []string{"id", "username", "email"} {
+		if v, ok := custom["person."+k]; ok {
+			delete(custom, "person."+k)
+			person[k] = v
+		}
+	}
+	if len(person) > 0 {
+		item["person"] = person
+	}
+	return item
+}
+
+// traceItem returns the item reporting err along with the stack ptrs.
+func (c *httpClient) traceItem(level string, err error, ptrs []uintptr, custom map[string]interface{}) map[string]interface{} {
+	item := c.item(level, err.Error(), custom)
+	frames := rollbarFrames(ptrs)
+	if _, ok := item["fingerprint"]; !ok {
+		item["fingerprint"] = stackFingerprint(frames)
+	}
+	item["body"] = map[string]interface{}{"trace": rollbarTrace(err, frames)}
+	return item
+}
+
+// traceChainItem returns the item titled after err reporting chain, the
+// errors of which recorded their stack trace, outermost first.
+func (c *httpClient) traceChainItem(level string, err error, chain []error, custom map[string]interface{}) map[string]interface{} {
+	item := c.item(level, err.Error(), custom)
+	traces := make([]map[string]interface{}, len(chain))
+	for i, e := range chain {
+		frames := rollbarFrames(stackPCs(e.(stackTracer)))
+		if _, ok := item["fingerprint"]; !ok {
+			item["fingerprint"] = stackFingerprint(frames)
+		}
+		traces[i] = rollbarTrace(e, frames)
+	}
+	item["body"] = map[string]interface{}{"trace_chain": traces}
+	return item
+}
+
+// rollbarTrace returns the trace reporting err along with its frames.
+func rollbarTrace(err error, frames []map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"frames": frames,
+		"exception": map[string]interface{}{
+			"class":   errorClass(err),
+			"message": err.Error(),
+		},
+	}
+}
+
+// messageItem returns the item reporting msg without a stack.
+func (c *httpClient) messageItem(level, msg string, custom map[string]interface{}) map[string]interface{} {
+	item := c.item(level, msg, custom)
+	item["body"] = map[string]interface{}{
+		"message": map[string]interface{}{"body": msg},
+	}
+	return item
+}
+
+// knownFilePathPatterns are where the import paths of the files reported in
+// the frames start, as with roll.
+var knownFilePathPatterns = []string{
+	"github.com/",
+	"code.google.com/",
+	"bitbucket.org/",
+	"launchpad.net/",
+}
+
+// rollbarFrames returns the frames of ptrs, outermost first as Rollbar
+// expects them, with the file and function names shortened like roll's.
+func rollbarFrames(ptrs []uintptr) []map[string]interface{} {
+	var frames []map[string]interface{}
+	it := runtime.CallersFrames(ptrs)
+	for {
+		frame, more := it.Next()
+		if frame.PC != 0 {
+			frames = append([]map[string]interface{}{{
+				"filename": shortenFilePath(frame.File),
+				"method":   functionName(frame.Function),
+				"lineno":   frame.Line,
+			}}, frames...)
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// shortenFilePath returns the path of file from its import path, if known.
+func shortenFilePath(file string) string {
+	if i := strings.Index(file, "/src/pkg/"); i != -1 {
+		return file[i+len("/src/pkg/"):]
+	}
+	for _, pattern := range knownFilePathPatterns {
+		if i := strings.Index(file, pattern); i != -1 {
+			return file[i:]
+		}
+	}
+	return file
+}
+
+// functionName returns the name of function without the path of its
+// package.
+func functionName(function string) string {
+	return function[strings.LastIndex(function, "/")+1:]
+}
+
+// stackFingerprint returns the fingerprint of the item reporting frames, a
+// hash of their files, functions and lines like roll's.
+func stackFingerprint(frames []map[string]interface{}) string {
+	hash := crc32.NewIEEE()
+	for _, frame := range frames {
+		fmt.Fprintf(hash, "%s%s%d", frame["filename"], frame["method"], frame["lineno"])
+	}
+	return fmt.Sprintf("%x", hash.Sum32())
+}
+
+// errorClass returns the class of err reported to Rollbar, like roll's: the
+// name of its type. Errors created by errors.New and fmt.Errorf without %w
+// all have the same type, so their class is derived from their message so
+// that Rollbar doesn't group them together.
+func errorClass(err error) string {
+	class := reflect.TypeOf(err).String()
+	if class == "" {
+		return "panic"
+	}
+	if class == "*errors.errorString" {
+		return fmt.Sprintf("{%x}", adler32.Checksum([]byte(err.Error())))
+	}
+	return strings.TrimPrefix(class, "*")
+}
+
+// send posts item to Rollbar and returns its UUID. Errors other than network
+// errors read "received response: <status>", as with roll.
+func (c *httpClient) send(item map[string]interface{}) (string, error) {
+	if c.transform != nil {
+		item = c.transform(item)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"access_token": c.token,
+		"data":         item,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.client.Post(c.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received response: %s", resp.Status)
+	}
+
+	var result struct {
+		Result struct {
+			UUID string `json:"uuid"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Result.UUID, nil
+}
@@ -0,0 +1,395 @@
+package rollrus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHTTPClient(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire:   true,
+		HTTPClient: &http.Client{Timeout: time.Second},
+		Endpoint:   server.URL,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("user", "bob").Error("through a custom client")
+
+	payload := <-items
+	if payload["access_token"] != "some-token" {
+		t.Fatal("Expected the access token to be sent, got: ", payload["access_token"])
+	}
+
+	data := payload["data"].(map[string]interface{})
+	if data["environment"] != "test" || data["level"] != SeverityError || data["title"] != "through a custom client" {
+		t.Fatalf("Unexpected item: %v", data)
+	}
+
+	if custom := data["custom"].(map[string]interface{}); custom["user"] != "bob" {
+		t.Fatalf("Expected the fields to be sent, got: %v", custom)
+	}
+
+	trace := data["body"].(map[string]interface{})["trace"].(map[string]interface{})
+	frames := trace["frames"].([]interface{})
+	last := frames[len(frames)-1].(map[string]interface{})
+	if !strings.HasSuffix(last["method"].(string), ".TestHTTPClient") {
+		t.Fatal("Expected the log call site to be the innermost frame, got: ", last["method"])
+	}
+}
+
+func TestHTTPClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newRollClient("some-token", "test", RollrusConfig{Endpoint: server.URL})
+
+	_, err := client.Info("unavailable", nil)
+	if err == nil || err.Error() != "received response: 503 Service Unavailable" {
+		t.Fatal("Expected the status to be returned as with roll, got: ", err)
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire: true,
+		Hostname: "web-1",
+		Endpoint: server.URL,
+		Transformer: func(item map[string]interface{}) map[string]interface{} {
+			item["server"].(map[string]interface{})["pid"] = 42
+			item["fingerprint"] = "custom"
+			return item
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("transformed")
+
+	item := <-items
+	if server := item["server"].(map[string]interface{}); server["host"] != "web-1" || server["pid"] != float64(42) {
+		t.Fatalf("Expected the server to be overridden, got: %v", server)
+	}
+
+	if item["fingerprint"] != "custom" || item["title"] != "transformed" {
+		t.Fatalf("Unexpected item: %v", item)
+	}
+}
+
+func TestServerRoot(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire:    true,
+		Endpoint:    server.URL,
+		CodeVersion: "8c3f5e2",
+		ServerRoot:  "github.com/org/repo",
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("linked to the source")
+
+	data := <-items
+	if data["code_version"] != "8c3f5e2" {
+		t.Fatal("Expected the code version to be reported, got: ", data["code_version"])
+	}
+	if root := data["server"].(map[string]interface{})["root"]; root != "github.com/org/repo" {
+		t.Fatal("Expected the server root to be reported, got: ", root)
+	}
+	if _, ok := data["custom"].(map[string]interface{})["code_version"]; ok {
+		t.Fatal("Expected the code version not to be reported as a custom field")
+	}
+}
+
+func TestHTTPClientRichFields(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire:   true,
+		Endpoint:   server.URL,
+		RichFields: true,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"status_code": 500,
+		"retried":     true,
+		"path":        "/users",
+		"id":          "42",
+	}).Error("typed")
+
+	custom := (<-items)["custom"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"status_code": float64(500),
+		"retried":     true,
+		"path":        "/users",
+		"id":          "42",
+	}
+	for k, v := range expected {
+		if custom[k] != v {
+			t.Fatalf("Expected %s to be %#v, got %#v", k, v, custom[k])
+		}
+	}
+}
+
+func TestHTTPClientFingerprint(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire: true,
+		Endpoint: server.URL,
+		FingerprintFunc: func(entry *logrus.Entry) string {
+			return "db-timeout"
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("query timed out")
+
+	data := <-items
+	if data["fingerprint"] != "db-timeout" {
+		t.Fatal("Expected the fingerprint of the item to be set, got: ", data["fingerprint"])
+	}
+	if custom := data["custom"].(map[string]interface{}); custom["fingerprint"] != nil {
+		t.Fatal("Expected the fingerprint not to be a custom field, got: ", custom["fingerprint"])
+	}
+}
+
+func TestHTTPClientPerson(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire: true,
+		Endpoint: server.URL,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{"user_id": "42", "user_email": "bob@example.com"}).Error("payment failed")
+
+	data := <-items
+	person, _ := data["person"].(map[string]interface{})
+	if person["id"] != "42" || person["email"] != "bob@example.com" {
+		t.Fatal("Expected the person of the item to be set, got: ", data["person"])
+	}
+	if _, ok := person["username"]; ok {
+		t.Fatal("Expected no username, got: ", person["username"])
+	}
+	if custom := data["custom"].(map[string]interface{}); custom["person.id"] != nil {
+		t.Fatal("Expected the person not to be a custom field, got: ", custom)
+	}
+}
+
+func TestHTTPClientPersonFromEntry(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire:   true,
+		Endpoint:   server.URL,
+		RichFields: true,
+		PersonFromEntry: func(entry *logrus.Entry) Person {
+			return Person{ID: "7", Username: entry.Data["login"].(string)}
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("login", "alice").Error("payment failed")
+
+	data := <-items
+	person, _ := data["person"].(map[string]interface{})
+	if person["id"] != "7" || person["username"] != "alice" {
+		t.Fatal("Expected the person of the item to be set, got: ", data["person"])
+	}
+}
+
+func TestHTTPClientStackFingerprint(t *testing.T) {
+	items := make(chan map[string]interface{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire: true,
+		Endpoint: server.URL,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for _, msg := range []string{"first", "second"} {
+		log.Error(msg)
+	}
+
+	first, second := <-items, <-items
+	if first["fingerprint"] == nil || first["fingerprint"] != second["fingerprint"] {
+		t.Fatalf("Expected the same fingerprint from the same frames, got %v and %v", first["fingerprint"], second["fingerprint"])
+	}
+
+	trace := first["body"].(map[string]interface{})["trace"].(map[string]interface{})
+	frames := trace["frames"].([]interface{})
+	last := frames[len(frames)-1].(map[string]interface{})
+	if method := last["method"].(string); strings.Contains(method, "/") {
+		t.Fatal("Expected the package path to be trimmed from the method, got: ", method)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	for err, expected := range map[error]string{
+		errors.New("boom"): "{42401ae}",
+		fmt.Errorf("wrapped: %w", errors.New("boom")): "fmt.wrapError",
+		&deliveryError{err: errors.New("boom")}:       "rollrus.deliveryError",
+	} {
+		if class := errorClass(err); class != expected {
+			t.Errorf("Expected the class of %v to be %q, got %q", err, expected, class)
+		}
+	}
+}
+
+func TestHTTPClientTraceChain(t *testing.T) {
+	items := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		items <- payload["data"].(map[string]interface{})
+		w.Write([]byte(`{"result":{"uuid":"abc"}}`))
+	}))
+	defer server.Close()
+
+	hook := NewHookForLevels("some-token", "test", RollrusConfig{
+		SyncFire:   true,
+		Endpoint:   server.URL,
+		WrapCauses: true,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	err := pkgerrors.New("disk full")
+	err = pkgerrors.Wrap(err, "writing block")
+	err = pkgerrors.Wrap(err, "saving file")
+	log.WithError(err).Error("with causes")
+
+	data := <-items
+	chain, _ := data["body"].(map[string]interface{})["trace_chain"].([]interface{})
+	expected := []string{
+		"saving file: writing block: disk full",
+		"writing block: disk full",
+		"disk full",
+	}
+	if len(chain) != len(expected) {
+		t.Fatalf("Expected %d traces, got: %v", len(expected), data["body"])
+	}
+	for i, msg := range expected {
+		trace := chain[i].(map[string]interface{})
+		if got := trace["exception"].(map[string]interface{})["message"]; got != msg {
+			t.Errorf("Expected trace %d to report %q, got %q", i, msg, got)
+		}
+		if frames := trace["frames"].([]interface{}); len(frames) == 0 {
+			t.Errorf("Expected trace %d to hold frames", i)
+		}
+	}
+
+	for k := range data["custom"].(map[string]interface{}) {
+		if strings.Contains(k, "trace_chain") {
+			t.Fatal("Expected the chain not to be reported as custom fields, got: ", k)
+		}
+	}
+}
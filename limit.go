@@ -0,0 +1,26 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// Limiter decides whether an entry may be sent to Rollbar. Implementations
+// must be safe for concurrent use, as Fire may be called from many goroutines.
+type Limiter interface {
+	Allow(entry *log.Entry) bool
+}
+
+type rateLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter returns a token bucket Limiter that allows limit entries per
+// second, with bursts of up to burst entries.
+func NewRateLimiter(limit rate.Limit, burst int) Limiter {
+	return rateLimiter{limiter: rate.NewLimiter(limit, burst)}
+}
+
+func (l rateLimiter) Allow(entry *log.Entry) bool {
+	return l.limiter.Allow()
+}
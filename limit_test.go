@@ -0,0 +1,70 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimit(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers: 1,
+		RateLimit:  rate.Every(time.Hour),
+		RateBurst:  2,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 10; i++ {
+		log.Error("limited")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 2 {
+		t.Fatalf("Expected 2 entries to be sent, got %d", n)
+	}
+
+	if n := hook.DroppedCount(); n != 8 {
+		t.Fatalf("Expected 8 entries to be dropped, got %d", n)
+	}
+}
+
+type levelLimiter logrus.Level
+
+func (l levelLimiter) Allow(entry *logrus.Entry) bool {
+	return entry.Level <= logrus.Level(l)
+}
+
+func TestLimiter(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:  true,
+		LogLevels: []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel},
+		Limiter:   levelLimiter(logrus.ErrorLevel),
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("allowed")
+	log.Warn("limited")
+
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected 1 entry to be sent, got %d", n)
+	}
+
+	if n := hook.DroppedCount(); n != 1 {
+		t.Fatalf("Expected 1 entry to be dropped, got %d", n)
+	}
+}
@@ -0,0 +1,93 @@
+package rollrus
+
+import "time"
+
+// Metrics receives the events of a hook as they happen, to report them to
+// statsd, Prometheus or any other metrics system. Its methods are called from
+// the logging goroutines and the workers, so they must be safe for concurrent
+// use and must not block. Embed NopMetrics to implement only some of them.
+type Metrics interface {
+	// IncSent is called for every entry sent to Rollbar.
+	IncSent()
+	// IncFailed is called for every entry that could not be sent.
+	IncFailed()
+	// IncDropped is called for every entry that was never sent, e.g.
+	// because it was filtered, sampled out, rate limited or because the
+	// buffer was full.
+	IncDropped()
+	// ObserveLatency is called with the time it took to send an entry,
+	// retries included, whether it succeeded or not.
+	ObserveLatency(d time.Duration)
+	// SetBufferDepth is called with the number of entries waiting to be
+	// sent whenever it changes.
+	SetBufferDepth(n int)
+}
+
+// FireMetrics is implemented by Metrics that also count the entries fired,
+// before they are filtered, sampled or rate limited.
+type FireMetrics interface {
+	// IncFired is called for every entry passed to Fire.
+	IncFired()
+}
+
+// NopMetrics is a Metrics ignoring every event, the default.
+type NopMetrics struct{}
+
+func (NopMetrics) IncSent()                       {}
+func (NopMetrics) IncFailed()                     {}
+func (NopMetrics) IncDropped()                    {}
+func (NopMetrics) ObserveLatency(d time.Duration) {}
+func (NopMetrics) SetBufferDepth(n int)           {}
+
+// metricsList is a Metrics passing the events on to each of its Metrics.
+type metricsList []Metrics
+
+// joinMetrics returns a Metrics passing the events on to a and b, either of
+// which can be nil.
+func joinMetrics(a, b Metrics) Metrics {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return metricsList{a, b}
+}
+
+func (l metricsList) IncFired() {
+	for _, m := range l {
+		if fm, ok := m.(FireMetrics); ok {
+			fm.IncFired()
+		}
+	}
+}
+
+func (l metricsList) IncSent() {
+	for _, m := range l {
+		m.IncSent()
+	}
+}
+
+func (l metricsList) IncFailed() {
+	for _, m := range l {
+		m.IncFailed()
+	}
+}
+
+func (l metricsList) IncDropped() {
+	for _, m := range l {
+		m.IncDropped()
+	}
+}
+
+func (l metricsList) ObserveLatency(d time.Duration) {
+	for _, m := range l {
+		m.ObserveLatency(d)
+	}
+}
+
+func (l metricsList) SetBufferDepth(n int) {
+	for _, m := range l {
+		m.SetBufferDepth(n)
+	}
+}
@@ -0,0 +1,86 @@
+// Package expvar publishes the counters of a rollrus hook with the standard
+// expvar package, so that they are served at /debug/vars. Hooks created with
+// a token use it on their own, see rollrus.RollrusConfig.ExpvarPrefix.
+package expvar
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// The counters of the Metrics.
+const (
+	FiresKey     = "rollrus_fires_total"
+	DropsKey     = "rollrus_drops_total"
+	SuccessesKey = "rollrus_successes_total"
+	FailuresKey  = "rollrus_failures_total"
+)
+
+// Metrics is a rollrus.Metrics and rollrus.FireMetrics counting the entries
+// of a hook in an expvar.Map:
+//
+//	rollrus_fires_total       entries passed to Fire
+//	rollrus_drops_total       entries that were never sent
+//	rollrus_successes_total   entries sent to Rollbar
+//	rollrus_failures_total    entries that could not be sent
+type Metrics struct {
+	fires     *expvar.Int
+	drops     *expvar.Int
+	successes *expvar.Int
+	failures  *expvar.Int
+}
+
+// mu serializes the lookup and creation of the maps, as expvar panics when a
+// name is published twice.
+var mu sync.Mutex
+
+// New returns Metrics publishing the counters in the expvar.Map named name.
+// Metrics with the same name share their counters.
+func New(name string) *Metrics {
+	mu.Lock()
+	defer mu.Unlock()
+
+	vars, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		vars = expvar.NewMap(name)
+	}
+
+	return &Metrics{
+		fires:     counter(vars, FiresKey),
+		drops:     counter(vars, DropsKey),
+		successes: counter(vars, SuccessesKey),
+		failures:  counter(vars, FailuresKey),
+	}
+}
+
+// NewForToken works like New, naming the map after the last 4 characters of
+// the Rollbar access token of the hook, e.g. "rollrus_a1b2", so that hooks
+// reporting to different projects don't share their counters.
+func NewForToken(token string) *Metrics {
+	return NewWithPrefix("rollrus", token)
+}
+
+// NewWithPrefix works like NewForToken, with prefix instead of "rollrus".
+func NewWithPrefix(prefix, token string) *Metrics {
+	if len(token) > 4 {
+		token = token[len(token)-4:]
+	}
+	return New(prefix + "_" + token)
+}
+
+func counter(vars *expvar.Map, key string) *expvar.Int {
+	if v, ok := vars.Get(key).(*expvar.Int); ok {
+		return v
+	}
+	v := new(expvar.Int)
+	vars.Set(key, v)
+	return v
+}
+
+func (m *Metrics) IncFired()                      { m.fires.Add(1) }
+func (m *Metrics) IncSent()                       { m.successes.Add(1) }
+func (m *Metrics) IncFailed()                     { m.failures.Add(1) }
+func (m *Metrics) IncDropped()                    { m.drops.Add(1) }
+func (m *Metrics) ObserveLatency(d time.Duration) {}
+func (m *Metrics) SetBufferDepth(n int)           {}
@@ -0,0 +1,133 @@
+package expvar_test
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benjamindow/rollrus"
+	metrics "github.com/benjamindow/rollrus/metrics/expvar"
+	"github.com/sirupsen/logrus"
+	"github.com/stvp/roll"
+)
+
+type failingClient struct {
+	roll.Client
+}
+
+func (failingClient) ErrorStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	if err.Error() == "unreachable" {
+		return "", errors.New("received response: 503 Service Unavailable")
+	}
+	return "", nil
+}
+
+// values returns the counters of the expvar.Map named name. They are global
+// to the process, so tests check how much they changed.
+func values(name string) map[string]int64 {
+	metrics.New(name)
+	vars := expvar.Get(name).(*expvar.Map)
+	m := make(map[string]int64)
+	for _, k := range []string{metrics.FiresKey, metrics.SuccessesKey, metrics.DropsKey, metrics.FailuresKey} {
+		m[k] = vars.Get(k).(*expvar.Int).Value()
+	}
+	return m
+}
+
+func TestMetrics(t *testing.T) {
+	before := values("rollrus_1a2b")
+	m := metrics.NewForToken("some-token-1a2b")
+	hook := rollrus.NewHookWithClient(failingClient{}, rollrus.RollrusConfig{
+		SyncFire:    true,
+		ErrorLogger: ioutil.Discard,
+		Metrics:     m,
+		Filter: func(entry *logrus.Entry) bool {
+			return entry.Message != "filtered"
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("sent")
+	log.Error("sent")
+	log.Error("filtered")
+	log.Error("unreachable")
+
+	vars, ok := expvar.Get("rollrus_1a2b").(*expvar.Map)
+	if !ok {
+		t.Fatal("Expected the counters to be published under rollrus_1a2b")
+	}
+
+	expected := map[string]int64{
+		metrics.FiresKey:     4,
+		metrics.SuccessesKey: 2,
+		metrics.DropsKey:     1,
+		metrics.FailuresKey:  1,
+	}
+	for k, v := range expected {
+		if got := vars.Get(k).(*expvar.Int).Value() - before[k]; got != v {
+			t.Fatalf("Expected %s to grow by %d, got %d", k, v, got)
+		}
+	}
+
+	metrics.New("rollrus_1a2b").IncFired()
+	if got := values("rollrus_1a2b")[metrics.FiresKey] - before[metrics.FiresKey]; got != 5 {
+		t.Fatal("Expected Metrics with the same name to share their counters")
+	}
+}
+
+func TestNewConcurrent(t *testing.T) {
+	name := fmt.Sprintf("rollrus_concurrent_%d", time.Now().UnixNano())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metrics.New(name).IncFired()
+		}()
+	}
+	wg.Wait()
+
+	if n := values(name)[metrics.FiresKey]; n != 8 {
+		t.Fatalf("Expected the hooks to share their counters, got %d fires", n)
+	}
+}
+
+func TestExpvarPrefix(t *testing.T) {
+	name := fmt.Sprintf("rollrus_prefix_%d", time.Now().UnixNano())
+	other := name + "_other"
+	hook := rollrus.NewHookWithClient(failingClient{}, rollrus.RollrusConfig{
+		SyncFire:     true,
+		ExpvarPrefix: name,
+		Metrics:      metrics.New(other),
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("sent")
+
+	for _, n := range []string{name, other} {
+		if v := values(n); v[metrics.FiresKey] != 1 || v[metrics.SuccessesKey] != 1 {
+			t.Fatalf("Expected the entry to be counted under %s, got %v", n, v)
+		}
+	}
+}
+
+func TestExpvarForToken(t *testing.T) {
+	hook := rollrus.NewHookForLevels("some-token-9z9z", "test", rollrus.RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	if _, ok := expvar.Get("rollrus_9z9z").(*expvar.Map); !ok {
+		t.Fatal("Expected the counters to be published under rollrus_9z9z without any setup")
+	}
+}
@@ -0,0 +1,58 @@
+// Package prometheus exports the counters of a rollrus hook as Prometheus
+// metrics.
+package prometheus
+
+import (
+	"github.com/benjamindow/rollrus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector reporting the Stats of a Hook:
+//
+//	rollrus_queue_depth             entries waiting to be sent
+//	rollrus_dropped_entries_total   entries that were never sent
+//	rollrus_sent_entries_total      entries sent to Rollbar
+//	rollrus_failed_entries_total    entries that could not be sent
+//
+// Register it once per hook, with prometheus.WrapRegistererWith to tell
+// several hooks apart.
+type Collector struct {
+	hook *rollrus.Hook
+
+	queueDepth *prometheus.Desc
+	dropped    *prometheus.Desc
+	sent       *prometheus.Desc
+	failed     *prometheus.Desc
+}
+
+// NewCollector returns a Collector reporting the counters of hook.
+func NewCollector(hook *rollrus.Hook) *Collector {
+	return &Collector{
+		hook: hook,
+		queueDepth: prometheus.NewDesc("rollrus_queue_depth",
+			"Number of entries waiting to be sent to Rollbar.", nil, nil),
+		dropped: prometheus.NewDesc("rollrus_dropped_entries_total",
+			"Number of entries that were never sent to Rollbar.", nil, nil),
+		sent: prometheus.NewDesc("rollrus_sent_entries_total",
+			"Number of entries sent to Rollbar.", nil, nil),
+		failed: prometheus.NewDesc("rollrus_failed_entries_total",
+			"Number of entries that could not be sent to Rollbar.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queueDepth
+	ch <- c.dropped
+	ch <- c.sent
+	ch <- c.failed
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.hook.Stats()
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(c.hook.QueueDepth()))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.sent, prometheus.CounterValue, float64(stats.Sent))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+}
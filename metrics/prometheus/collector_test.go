@@ -0,0 +1,58 @@
+package prometheus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/benjamindow/rollrus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCollector(t *testing.T) {
+	hook := rollrus.NewTestHookForLevels(rollrus.RollrusConfig{
+		Filter: func(entry *logrus.Entry) bool {
+			return entry.Message != "filtered"
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("sent")
+	log.Error("filtered")
+	log.Error("filtered")
+	hook.Flush()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector(hook.Hook))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]float64)
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		if m.GetCounter() != nil {
+			values[f.GetName()] = m.GetCounter().GetValue()
+		} else {
+			values[f.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+
+	expected := map[string]float64{
+		"rollrus_queue_depth":           0,
+		"rollrus_dropped_entries_total": 2,
+		"rollrus_sent_entries_total":    1,
+		"rollrus_failed_entries_total":  0,
+	}
+	for k, v := range expected {
+		if values[k] != v {
+			t.Fatalf("Expected %s to equal %v, but instead it is: %v", k, v, values[k])
+		}
+	}
+}
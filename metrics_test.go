@@ -0,0 +1,101 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordingMetrics counts the events it receives.
+type recordingMetrics struct {
+	mu                    sync.Mutex
+	sent, failed, dropped int
+	calls, depth          int
+}
+
+func (m *recordingMetrics) IncSent() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent++
+}
+
+func (m *recordingMetrics) IncFailed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed++
+}
+
+func (m *recordingMetrics) IncDropped() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped++
+}
+
+func (m *recordingMetrics) ObserveLatency(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+}
+
+func (m *recordingMetrics) SetBufferDepth(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depth = n
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers: 1,
+		Metrics:    metrics,
+		Filter: func(entry *logrus.Entry) bool {
+			return entry.Message != "filtered"
+		},
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("sent")
+	log.Error("filtered")
+	log.Error("sent")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.sent != 2 || metrics.dropped != 1 || metrics.calls != 2 || metrics.depth != 0 {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestMetricsFailed(t *testing.T) {
+	metrics := &recordingMetrics{}
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:    true,
+		Metrics:     metrics,
+		ErrorLogger: ioutil.Discard,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("failed")
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.failed != 1 || metrics.sent != 0 || metrics.calls != 1 {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+}
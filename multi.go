@@ -0,0 +1,167 @@
+package rollrus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MultiHook reports entries through several hooks, for instance to send them
+// to several Rollbar projects.
+type MultiHook struct {
+	hooks []*Hook
+}
+
+// NewMultiHook returns a hook reporting entries through all of hooks.
+func NewMultiHook(hooks ...*Hook) *MultiHook {
+	return &MultiHook{hooks: hooks}
+}
+
+// NewPerLevelHook sets up a hook with a buffer and worker pool of its own for
+// each of the reporting levels, so that a flood of entries of one level can't
+// delay the entries of the others, e.g. fatal entries behind errors. Each pool
+// has config.NumWorkers workers, and config.Buffer is ignored. The hooks share
+// the Limiter, or the RateLimit, so that they don't send more entries
+// together than a single hook would.
+//
+// It returns a MultiHook rather than a Hook, as every level is reported by a
+// Hook of its own; Stats and the other accessors are on those hooks.
+func NewPerLevelHook(token string, env string, config RollrusConfig) *MultiHook {
+	return NewPerLevelHookWithClient(newRollClient(token, env, config), config)
+}
+
+// NewPerLevelHookWithClient works like NewPerLevelHook, but reports through
+// client, see NewHookWithClient.
+func NewPerLevelHookWithClient(client RollClient, config RollrusConfig) *MultiHook {
+	levels := config.LogLevels
+	if len(levels) == 0 {
+		levels = defaultTriggerLevels
+	}
+
+	if config.Limiter == nil && config.RateLimit > 0 {
+		burst := config.RateBurst
+		if burst == 0 {
+			burst = 1
+		}
+		config.Limiter = NewRateLimiter(config.RateLimit, burst)
+	}
+
+	hooks := make([]*Hook, len(levels))
+	for i, level := range levels {
+		c := config
+		c.LogLevels = []log.Level{level}
+		c.Buffer = nil
+		hooks[i] = NewHookWithClient(client, c)
+	}
+	return NewMultiHook(hooks...)
+}
+
+// Fire fires entry concurrently on the hooks reporting its level, and
+// returns the errors they returned combined.
+func (m *MultiHook) Fire(entry *log.Entry) error {
+	// Record the stack here, the hooks would otherwise record the stack of
+	// the goroutines they are fired from.
+	entry = withStack(entry)
+
+	errs := make([]error, len(m.hooks))
+	var wg sync.WaitGroup
+	for i, h := range m.hooks {
+		if !firesOn(h, entry.Level) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, h *Hook) {
+			defer wg.Done()
+			errs[i] = h.Fire(entry)
+		}(i, h)
+	}
+	wg.Wait()
+
+	return combineErrors(errs)
+}
+
+// Levels returns the levels reported by any of the hooks.
+func (m *MultiHook) Levels() []log.Level {
+	var levels []log.Level
+	seen := make(map[log.Level]bool)
+	for _, h := range m.hooks {
+		for _, level := range h.Levels() {
+			if !seen[level] {
+				seen[level] = true
+				levels = append(levels, level)
+			}
+		}
+	}
+	return levels
+}
+
+// Flush flushes all the hooks, see Hook.Flush.
+func (m *MultiHook) Flush() error {
+	errs := make([]error, len(m.hooks))
+	for i, h := range m.hooks {
+		errs[i] = h.Flush()
+	}
+	return combineErrors(errs)
+}
+
+// FlushWithContext flushes all the hooks until ctx is done, see
+// Hook.FlushWithContext.
+func (m *MultiHook) FlushWithContext(ctx context.Context) error {
+	errs := make([]error, len(m.hooks))
+	for i, h := range m.hooks {
+		errs[i] = h.FlushWithContext(ctx)
+	}
+	return combineErrors(errs)
+}
+
+// Close closes all the hooks, see Hook.Close.
+func (m *MultiHook) Close() error {
+	errs := make([]error, len(m.hooks))
+	for i, h := range m.hooks {
+		errs[i] = h.Close()
+	}
+	return combineErrors(errs)
+}
+
+func firesOn(h *Hook, level log.Level) bool {
+	for _, l := range h.Levels() {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// multiError is the error returned when several hooks failed.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("rollrus: %d hooks failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// combineErrors returns nil when all errs are nil, the only non nil error, or
+// a multiError of the non nil errors.
+func combineErrors(errs []error) error {
+	var failed multiError
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	}
+	return failed
+}
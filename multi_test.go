@@ -0,0 +1,115 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMultiHook(t *testing.T) {
+	errorClient := &fakeClient{}
+	warnClient := &fakeClient{}
+	hook := NewMultiHook(
+		NewHookWithClient(errorClient, RollrusConfig{SyncFire: true}),
+		NewHookWithClient(warnClient, RollrusConfig{
+			SyncFire:  true,
+			LogLevels: []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel},
+		}),
+	)
+
+	if n := len(hook.Levels()); n != 4 {
+		t.Fatalf("Expected the union of 4 levels, got %v", hook.Levels())
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("both")
+	log.Warn("warnings only")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(errorClient.Items()); n != 1 {
+		t.Fatalf("Expected 1 item on the first hook, got %d", n)
+	}
+
+	if n := len(warnClient.Items()); n != 2 {
+		t.Fatalf("Expected 2 items on the second hook, got %d", n)
+	}
+
+	if len(warnClient.Items()[0].stack) == 0 {
+		t.Fatal("Expected the stack to be reported")
+	}
+}
+
+func TestMultiHookErrors(t *testing.T) {
+	hook := NewMultiHook(
+		NewHookWithClient(&fakeClient{err: errors.New("first")}, RollrusConfig{SyncFire: true, ErrorLogger: ioutil.Discard}),
+		NewHookWithClient(&fakeClient{}, RollrusConfig{SyncFire: true}),
+		NewHookWithClient(&fakeClient{err: errors.New("second")}, RollrusConfig{SyncFire: true, ErrorLogger: ioutil.Discard}),
+	)
+	defer hook.Close()
+
+	err := hook.Fire(&logrus.Entry{Level: logrus.ErrorLevel, Message: "failing", Data: logrus.Fields{}})
+	if err == nil || !strings.Contains(err.Error(), "first") || !strings.Contains(err.Error(), "second") {
+		t.Fatal("Expected the errors of both failing hooks, got: ", err)
+	}
+}
+
+func TestPerLevelHook(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewPerLevelHookWithClient(client, RollrusConfig{
+		NumWorkers: 1,
+		LogLevels:  []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel},
+	})
+
+	if n := len(hook.hooks); n != 2 {
+		t.Fatalf("Expected a hook per level, got %d", n)
+	}
+	if hook.hooks[0].entries == hook.hooks[1].entries {
+		t.Fatal("Expected each level to have its own buffer")
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("error")
+	log.Warn("warning")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 2 {
+		t.Fatalf("Expected Close to drain every buffer, got %d items", n)
+	}
+}
+
+func TestPerLevelHookRateLimit(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewPerLevelHookWithClient(client, RollrusConfig{
+		SyncFire:  true,
+		RateLimit: 0.001,
+		RateBurst: 1,
+		LogLevels: []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("error")
+	log.Warn("warning")
+
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected the hooks to share the rate limit, got %d items", n)
+	}
+}
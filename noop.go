@@ -0,0 +1,59 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// NewNoopHook returns a hook handling every level whose Fire returns right
+// away, to stand in for a Hook in tests or where reporting is disabled. It
+// runs no goroutines and closing it returns immediately. Its levels can be
+// narrowed with SetLevels.
+func NewNoopHook() *Hook {
+	h := NewHookWithClient(nopClient{}, RollrusConfig{SyncFire: true})
+	h.noop = true
+	h.SetLevels(log.AllLevels)
+	return h
+}
+
+// newInactiveHook returns a noop hook handling no levels, so that logrus
+// doesn't even call its Fire.
+func newInactiveHook() *Hook {
+	h := NewNoopHook()
+	h.SetLevels(nil)
+	return h
+}
+
+// nopClient is a roll.Client reporting nothing.
+type nopClient struct{}
+
+func (nopClient) Critical(err error, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) CriticalStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) Error(err error, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) ErrorStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) Warning(err error, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) WarningStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) Info(msg string, custom map[string]string) (string, error) {
+	return "", nil
+}
+
+func (nopClient) Debug(msg string, custom map[string]string) (string, error) {
+	return "", nil
+}
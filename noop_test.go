@@ -0,0 +1,42 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNoopHook(t *testing.T) {
+	hook := NewNoopHook()
+
+	if !reflect.DeepEqual(hook.Levels(), logrus.AllLevels) {
+		t.Fatal("Expected the noop hook to handle every level, got: ", hook.Levels())
+	}
+	if n := hook.WorkerCount(); n != 0 {
+		t.Fatalf("Expected no workers, got %d", n)
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("user", "bob").Error("ignored")
+	if err := hook.Fire(log.WithField("user", "bob")); err != nil {
+		t.Fatal("Expected Fire to succeed, got: ", err)
+	}
+
+	if err := hook.Flush(); err != nil {
+		t.Fatal("Expected Flush to succeed, got: ", err)
+	}
+	if stats := hook.Stats(); stats != (Stats{}) {
+		t.Fatalf("Expected nothing to be counted, got %+v", stats)
+	}
+
+	start := time.Now()
+	if err := hook.Close(); err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Fatal("Expected the noop hook to close immediately, got: ", err)
+	}
+}
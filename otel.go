@@ -0,0 +1,43 @@
+package rollrus
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setSpanContext adds the trace context of the OpenTelemetry span in ctx to m,
+// without overwriting fields that were set on the entry:
+//
+//	trace_id     the trace ID, as 32 hex digits
+//	span_id      the span ID, as 16 hex digits
+//	trace_flags  the trace flags, as 2 hex digits
+//	traceparent  the W3C traceparent header
+//	tracestate   the W3C tracestate header, when not empty
+func setSpanContext(m map[string]string, ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	fields := map[string]string{
+		"trace_id":    sc.TraceID().String(),
+		"span_id":     sc.SpanID().String(),
+		"trace_flags": sc.TraceFlags().String(),
+		"traceparent": fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags()),
+	}
+	if ts := sc.TraceState().String(); ts != "" {
+		fields["tracestate"] = ts
+	}
+
+	for k, v := range fields {
+		if _, exists := m[k]; !exists {
+			m[k] = v
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Person is the person an entry is about, see PersonFromEntry.
+type Person struct {
+	ID       string
+	Username string
+	Email    string
+}
+
+// PersonFromEntry returns the person entry is about. Empty values are left
+// out, an empty Person reports no person. Like the PersonFieldNames, the
+// person is only sent as Rollbar's person when the hook builds the items.
+type PersonFromEntry func(entry *log.Entry) Person
+
+// PersonFieldNames are the names of the fields identifying the person an
+// entry is about.
+type PersonFieldNames struct {
+	ID       string
+	Username string
+	Email    string
+}
+
+var defaultPersonFieldNames = PersonFieldNames{
+	ID:       "user_id",
+	Username: "user_name",
+	Email:    "user_email",
+}
+
+// setPerson moves the person fields of m to the person.id, person.username
+// and person.email keys, which become the person of the items built by the
+// hook and stay custom fields with roll.
+func (p PersonFieldNames) setPerson(m map[string]string) {
+	move := func(from, to string) {
+		if v, ok := m[from]; ok && from != "" {
+			delete(m, from)
+			m[to] = v
+		}
+	}
+
+	move(p.ID, "person.id")
+	move(p.Username, "person.username")
+	move(p.Email, "person.email")
+}
+
+// set reports the non empty values of p under the person keys of m, replacing
+// the values from the person fields.
+func (p Person) set(m map[string]string) {
+	for k, v := range map[string]string{
+		"person.id":       p.ID,
+		"person.username": p.Username,
+		"person.email":    p.Email,
+	} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+}
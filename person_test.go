@@ -0,0 +1,76 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPersonFields(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:         true,
+		PersonFieldNames: PersonFieldNames{ID: "uid", Email: "email"},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"uid":       42,
+		"email":     "bob@example.com",
+		"user_name": "bob",
+	}).Error("with person")
+
+	custom := client.Items()[0].custom
+	expected := map[string]string{
+		"person.id":    "42",
+		"person.email": "bob@example.com",
+		"user_name":    "bob",
+	}
+	for k, v := range expected {
+		if custom[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, custom[k])
+		}
+	}
+
+	if _, ok := custom["uid"]; ok {
+		t.Fatal("Expected uid to be reported as person.id only")
+	}
+}
+
+func TestPersonFromEntry(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire: true,
+		PersonFromEntry: func(entry *logrus.Entry) Person {
+			return Person{ID: "7", Username: entry.Data["login"].(string)}
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"login":      "alice",
+		"user_id":    42,
+		"user_email": "alice@example.com",
+	}).Error("with person")
+
+	custom := client.Items()[0].custom
+	expected := map[string]string{
+		"person.id":       "7",
+		"person.username": "alice",
+		"person.email":    "alice@example.com",
+	}
+	for k, v := range expected {
+		if custom[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, custom[k])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package rollrus
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type requestKey struct{}
+
+// WithRequest returns a copy of ctx carrying r. Entries logged with the
+// returned context, e.g. via log.WithContext, are reported to Rollbar with the
+// URL, method, user IP and headers of r, as fields prefixed with "request.".
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// Middleware returns a net/http middleware storing every request in its
+// context with WithRequest, so that entries logged with that context carry
+// the request. Panics in the handler are reported along with the request,
+// then re-panicked, see ReportPanicWithContext.
+func Middleware(h *Hook) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithRequest(r.Context(), r)
+			defer h.ReportPanicWithContext(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestFromContext returns the request stored in ctx by WithRequest.
+func requestFromContext(ctx context.Context) *http.Request {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+// requestFields returns the fields describing r that are reported to Rollbar.
+func requestFields(r *http.Request) log.Fields {
+	fields := log.Fields{
+		"request.url":     r.URL.String(),
+		"request.method":  r.Method,
+		"request.user_ip": userIP(r),
+	}
+	for name, values := range r.Header {
+		fields["request.headers."+name] = strings.Join(values, ", ")
+	}
+	return fields
+}
+
+func userIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// withRequestFields returns a copy of entry with the fields of the request
+// attached to its context, if any. The request is read before Fire returns
+// as handlers may reuse or modify it once they're done.
+func withRequestFields(entry *log.Entry) *log.Entry {
+	r := requestFromContext(entry.Context)
+	if r == nil {
+		return entry
+	}
+
+	data := requestFields(r)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+
+	e := *entry
+	e.Data = data
+	return &e
+}
@@ -0,0 +1,98 @@
+package rollrus
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithRequest(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	req := httptest.NewRequest("POST", "http://example.com/users?id=1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Request-Id", "abc123")
+	req.Header.Set("Cookie", "session=s3cr3t")
+	req.Header.Set("X-Api-Key", "k3y")
+
+	ctx := WithRequest(context.Background(), req)
+	log.WithContext(ctx).WithField("request.method", "field").Error("with request")
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	expected := map[string]string{
+		"request.url":                  "http://example.com/users?id=1",
+		"request.method":               "field",
+		"request.user_ip":              "10.0.0.1",
+		"request.headers.X-Request-Id": "abc123",
+		"request.headers.Cookie":       "[REDACTED]",
+		"request.headers.X-Api-Key":    "[REDACTED]",
+	}
+	for k, v := range expected {
+		if items[0].custom[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, items[0].custom[k])
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	handler := Middleware(hook)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.WithContext(r.Context()).Error("in handler")
+		if r.URL.Path == "/panic" {
+			panic("handler bug")
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/ok", nil))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "handler bug" {
+				t.Fatalf("Expected the panic to be re-panicked, got %v", p)
+			}
+		}()
+		req := httptest.NewRequest("GET", "http://example.com/panic", nil)
+		req.Header.Set("Authorization", "Bearer SECRET")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	items := client.Items()
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+
+	for i, url := range []string{"http://example.com/ok", "http://example.com/panic", "http://example.com/panic"} {
+		if items[i].custom["request.url"] != url {
+			t.Fatalf("Expected item %d to carry the request %s, got %q", i, url, items[i].custom["request.url"])
+		}
+	}
+
+	if items[2].severity != SeverityCritical {
+		t.Fatalf("Expected the panic to be reported as critical, got %s", items[2].severity)
+	}
+
+	if v := items[2].custom["request.headers.Authorization"]; v != "[REDACTED]" {
+		t.Fatal("Expected the Authorization header of the panic to be redacted, got: ", v)
+	}
+}
@@ -0,0 +1,80 @@
+package rollrus
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryPolicy retries failed reports with an exponential backoff and jitter.
+type retryPolicy struct {
+	max       int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// delay returns how long to wait before the retry following attempt: a
+// random duration between half and all of baseDelay doubled for every
+// attempt, capped at maxDelay.
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := p.baseDelay
+	for i := 1; i < attempt && d < p.maxDelay; i++ {
+		d *= 2
+	}
+	if d > p.maxDelay {
+		d = p.maxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// wait sleeps before retrying after attempt and reports whether to retry. It
+// returns false when attempt was the last one or stop is closed meanwhile.
+func (p retryPolicy) wait(attempt int, stop <-chan struct{}) bool {
+	if attempt > p.max {
+		return false
+	}
+
+	t := time.NewTimer(p.delay(attempt))
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// permanent reports whether err is a client error that retrying won't fix,
+// which roll reports as "received response: 4xx ...". Rate limited requests
+// are retried.
+func permanent(err error) bool {
+	const prefix = "received response: "
+	msg := err.Error()
+	i := strings.Index(msg, prefix)
+	if i < 0 || len(msg) < i+len(prefix)+3 {
+		return false
+	}
+
+	code, err := strconv.Atoi(msg[i+len(prefix) : i+len(prefix)+3])
+	return err == nil && code >= 400 && code < 500 && code != 429
+}
+
+// deliveryError is returned by sendToRollbar when an entry could not be sent,
+// after the given number of attempts.
+type deliveryError struct {
+	err      error
+	attempts int
+}
+
+func (e *deliveryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *deliveryError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,149 @@
+package rollrus
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRetryDelay(t *testing.T) {
+	p := retryPolicy{max: 10, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		if d := p.delay(attempt + 1); d < max/2 || d > max {
+			t.Fatalf("Expected the delay after attempt %d to be between %s and %s, got %s", attempt+1, max/2, max, d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var out bytes.Buffer
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers:     1,
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+		ErrorLogger:    &out,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("retried")
+
+	if err := hook.Flush(); err != nil {
+		t.Fatal("Expected Flush to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", n)
+	}
+
+	if !strings.Contains(out.String(), `"attempt":3`) {
+		t.Fatal("Expected the failure to be logged after the last attempt, got: ", out.String())
+	}
+
+	if stats := hook.Stats(); stats.Failed != 1 || stats.FailedAfterRetries != 1 {
+		t.Fatalf("Expected 1 failure after retries, got %+v", stats)
+	}
+
+	hook.Close()
+}
+
+func TestRetryPermanentFailure(t *testing.T) {
+	client := &fakeClient{err: errors.New("received response: 422 Unprocessable Entity")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:       true,
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+		ErrorLogger:    ioutil.Discard,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("not retried")
+
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected a 4xx response not to be retried, got %d attempts", n)
+	}
+
+	if stats := hook.Stats(); stats.Failed != 1 || stats.FailedAfterRetries != 0 {
+		t.Fatalf("Expected 1 failure without retries, got %+v", stats)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	for msg, expected := range map[string]bool{
+		"received response: 400 Bad Request":         true,
+		"received response: 429 Too Many Requests":   false,
+		"received response: 503 Service Unavailable": false,
+		"dial tcp: connection refused":               false,
+		"received response: ":                        false,
+	} {
+		if got := permanent(errors.New(msg)); got != expected {
+			t.Errorf("Expected permanent(%q) to be %v", msg, expected)
+		}
+	}
+}
+
+func TestRetryStopsOnClose(t *testing.T) {
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers:     1,
+		MaxRetries:     5,
+		RetryBaseDelay: time.Hour,
+		RetryMaxDelay:  time.Hour,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("retried")
+
+	start := time.Now()
+	if err := hook.CloseWithTimeout(0); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatal("Expected Close to interrupt the backoff, took ", elapsed)
+	}
+}
+
+func TestRetryStopsOnCloseSync(t *testing.T) {
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:       true,
+		ErrorLogger:    ioutil.Discard,
+		MaxRetries:     5,
+		RetryBaseDelay: time.Hour,
+		RetryMaxDelay:  time.Hour,
+	})
+
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	hook.FireAndForget(entry)
+	for len(client.Items()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := hook.CloseWithTimeout(5 * time.Second); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatal("Expected Close to interrupt the backoff, took ", elapsed)
+	}
+}
@@ -0,0 +1,89 @@
+package rollrus
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// convertFieldsRich works like convertFields, but keeps booleans and numbers
+// as they are and converts maps, slices and structs to their JSON structure.
+func convertFieldsRich(fields log.Fields) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		switch t := v.(type) {
+		case error:
+			errFields := make(map[string]string)
+			setError(errFields, k, t)
+			for ek, ev := range errFields {
+				m[ek] = ev
+			}
+		case string, bool, int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64, float32, float64:
+			m[k] = t
+		case time.Time, fmt.Stringer:
+			m[k] = formatValue(t)
+		default:
+			m[k] = jsonValue(t)
+		}
+	}
+
+	return m
+}
+
+// jsonValue returns v as decoded from its JSON encoding, or formatted when it
+// can't be encoded.
+func jsonValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return formatValue(v)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return formatValue(v)
+	}
+	return decoded
+}
+
+// encodeRichFields converts the values of m that aren't strings to JSON.
+// roll.Client only accepts string custom data, so this is how the structure of
+// rich fields reaches Rollbar.
+func encodeRichFields(m map[string]interface{}) map[string]string {
+	encoded := make(map[string]string, len(m))
+	for k, v := range m {
+		encoded[k] = encodeRichValue(v)
+	}
+
+	return encoded
+}
+
+func encodeRichValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return formatValue(v)
+	}
+	return string(data)
+}
+
+// restoreTypes returns the fields of m, with the values encoded from rich by
+// encodeRichFields decoded back to their type. Values changed since, e.g. by
+// BeforeSend, are left as strings.
+func restoreTypes(m map[string]string, rich map[string]interface{}) map[string]interface{} {
+	typed := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r, ok := rich[k]; ok && encodeRichValue(r) == v {
+			typed[k] = r
+		} else {
+			typed[k] = v
+		}
+	}
+
+	return typed
+}
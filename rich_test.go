@@ -0,0 +1,99 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConvertFieldsRich(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+
+	m := convertFieldsRich(logrus.Fields{
+		"count":  3,
+		"ratio":  0.5,
+		"ok":     true,
+		"name":   "bob",
+		"tags":   []string{"a", "b"},
+		"nested": map[string]interface{}{"point": point{1, 2}},
+		"err":    errors.New("failed"),
+	})
+
+	expected := map[string]interface{}{
+		"count":    3,
+		"ratio":    0.5,
+		"ok":       true,
+		"name":     "bob",
+		"tags":     []interface{}{"a", "b"},
+		"nested":   map[string]interface{}{"point": map[string]interface{}{"X": float64(1), "Y": float64(2)}},
+		"err":      "failed",
+		"err.type": "*errors.errorString",
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("Expected %v, got %v", expected, m)
+	}
+}
+
+func TestRichFields(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true, RichFields: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"ids":  []int{1, 2},
+		"user": map[string]string{"name": "bob"},
+		"ok":   true,
+	}).Error("rich")
+
+	custom := client.Items()[0].custom
+	expected := map[string]string{
+		"ids":  "[1,2]",
+		"user": `{"name":"bob"}`,
+		"ok":   "true",
+	}
+	for k, v := range expected {
+		if custom[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, custom[k])
+		}
+	}
+}
+
+func TestRichFieldsScrubbed(t *testing.T) {
+	type login struct {
+		User string `json:"user"`
+		Pwd  string `json:"password"`
+	}
+
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true, RichFields: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"creds": map[string]interface{}{"user": "bob", "password": "hunter2"},
+		"login": login{User: "bob", Pwd: "hunter2"},
+	}).Error("scrubbed")
+
+	custom := client.Items()[0].custom
+	expected := map[string]string{
+		"creds": `{"password":"[REDACTED]","user":"bob"}`,
+		"login": `{"password":"[REDACTED]","user":"bob"}`,
+	}
+	for k, v := range expected {
+		if custom[k] != v {
+			t.Fatalf("Expected %s to be %s, got %s", k, v, custom[k])
+		}
+	}
+}
@@ -1,17 +1,26 @@
 package rollrus
 
 import (
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"net/http"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/benjamindow/rollrus/buffer"
 	"github.com/benjamindow/rollrus/buffer/channel"
+	expvarmetrics "github.com/benjamindow/rollrus/metrics/expvar"
 	log "github.com/sirupsen/logrus"
 	"github.com/stvp/roll"
+	"golang.org/x/time/rate"
 )
 
 type noopCloser struct{}
@@ -24,6 +33,254 @@ type RollrusConfig struct {
 	Buffer     buffer.Buffer
 	NumWorkers int
 	LogLevels  []log.Level
+
+	// MaxWorkers makes the worker pool scale between MinWorkers and
+	// MaxWorkers instead of using NumWorkers workers: a worker is added
+	// whenever an entry finds none idle, and workers idle for about
+	// WorkerIdleTimeout are retired. Entries thus only queue up in the
+	// buffer once MaxWorkers workers are busy, so there is no queue depth
+	// threshold to tune.
+	MaxWorkers int
+	// MinWorkers defaults to 1.
+	MinWorkers int
+	// WorkerIdleTimeout defaults to 30 seconds.
+	WorkerIdleTimeout time.Duration
+
+	// BufferOverflowPolicy is what the default buffer does when it's full.
+	// Defaults to buffer.Block. It is ignored when Buffer is set.
+	BufferOverflowPolicy buffer.OverflowPolicy
+
+	// SyncFire makes Fire block until the entry has been sent to Rollbar,
+	// bypassing the buffer and worker pool. Useful for short lived
+	// processes that may exit before the workers get to an entry.
+	SyncFire bool
+	// FireMode sets how Fire hands entries over, see FireMode. FireModeSync
+	// is the same as SyncFire.
+	FireMode FireMode
+
+	// Enabled, when set, is called once by the constructors. When it
+	// returns false the hook handles no levels and reports nothing, not
+	// even panics, runs no workers, and closing it returns immediately. See
+	// EnabledFromEnv.
+	Enabled func() bool
+
+	// CloseTimeout is how long Close waits for buffered entries to be
+	// sent before giving up. Defaults to 5 seconds.
+	CloseTimeout time.Duration
+
+	// RateLimit caps how many entries per second are sent to Rollbar,
+	// entries over the limit are dropped by Fire. Zero means no limit.
+	RateLimit rate.Limit
+	// RateBurst is the number of entries that may be sent at once before
+	// RateLimit kicks in. Defaults to 1.
+	RateBurst int
+	// Limiter decides which entries are dropped by Fire. It takes
+	// precedence over RateLimit.
+	Limiter Limiter
+
+	// LevelSeverityMap overrides the Rollbar severity entries are reported
+	// with for the given levels, e.g. log.WarnLevel: SeverityWarning.
+	LevelSeverityMap map[log.Level]string
+
+	// ScrubFields are the field names, matched case insensitively, whose
+	// values are replaced with "[REDACTED]" before being sent to Rollbar.
+	// Defaults to DefaultScrubFields, set it to an empty slice to disable.
+	ScrubFields []string
+	// ScrubFunc is called for every other field. It returns the value to
+	// report and false if the field should be dropped instead.
+	ScrubFunc func(key string, value interface{}) (interface{}, bool)
+
+	// FieldBlacklist are the fields never reported, as path.Match patterns
+	// such as "*_token".
+	FieldBlacklist []string
+	// FieldWhitelist, when not empty, are the only fields reported, as
+	// path.Match patterns. It takes precedence over FieldBlacklist.
+	FieldWhitelist []string
+
+	// FlattenFields reports nested maps, structs and slices as dotted keys,
+	// e.g. user.id or items.0, instead of a single formatted value.
+	FlattenFields bool
+	// MaxFlattenDepth is how many levels deep FlattenFields goes. Defaults
+	// to 5.
+	MaxFlattenDepth int
+
+	// RichFields keeps the type of booleans and numbers and reports maps,
+	// slices and structs as JSON rather than formatting them with fmt. It is
+	// ignored when FlattenFields is set. roll only takes strings, so the
+	// values are reported with their type, e.g. for Rollbar's numeric
+	// filters, only when the hook sends the items itself, see HTTPClient.
+	// Otherwise they are reported as their JSON encoding.
+	RichFields bool
+
+	// SampleRate is the fraction, between 0 and 1, of entries that are sent
+	// to Rollbar, the others are dropped by Fire. Zero means all entries
+	// are sent.
+	SampleRate float64
+	// SampleRates overrides SampleRate for entries with the given messages,
+	// e.g. {"connection reset": 0.01}. A rate of 0 drops every such entry.
+	SampleRates map[string]float64
+
+	// Filter is called by Fire with every entry, which is dropped when it
+	// returns false. Fire may be called from any goroutine, so Filter must
+	// be safe for concurrent use.
+	Filter func(*log.Entry) bool
+
+	// DedupeWindow, when set, makes Fire coalesce identical entries: the
+	// first one is reported right away and the ones following it within
+	// DedupeWindow are suppressed. When the window closes, the last of them
+	// is reported with the number of entries logged during the window in
+	// its repeat_count field, and the number suppressed in suppressed_count.
+	DedupeWindow time.Duration
+	// DedupeKey identifies identical entries. Defaults to their level,
+	// message and fingerprint, see FingerprintField.
+	DedupeKey DedupeKeyFunc
+	// DedupeMaxKeys bounds the number of windows open at once. Opening one
+	// more closes the least recently used window, as if it had expired.
+	// Defaults to 1000.
+	DedupeMaxKeys int
+
+	// DisableStackCapture stops Fire from recording the stack of the log
+	// call site. Entries are then only reported with a stack trace when
+	// their error field carries one, see github.com/pkg/errors.
+	DisableStackCapture bool
+
+	// StackTraceField is the field holding the error whose stack trace,
+	// when it carries one, is reported instead of the stack recorded by
+	// Fire. Defaults to logrus.ErrorKey.
+	StackTraceField string
+
+	// WrapCauses reports the stack trace of every error wrapped by an error
+	// field, not only the innermost one. The items built by the hook, see
+	// HTTPClient, carry the chain of the StackTraceField error as their
+	// trace chain; otherwise the chains are reported as
+	// <field>.trace_chain.N fields.
+	WrapCauses bool
+
+	// WithOTelContext reports the trace context of the OpenTelemetry span
+	// in the context of the entry, if any, as the trace_id, span_id,
+	// trace_flags, traceparent and tracestate fields.
+	WithOTelContext bool
+
+	// BeforeSend is called by the workers with every entry and the fields
+	// about to be reported. See BeforeSendFunc.
+	BeforeSend BeforeSendFunc
+
+	// PersonFieldNames are the fields reported as the person an entry is
+	// about. Defaults to user_id, user_name and user_email. They're sent
+	// as the person of the item when the hook builds the items, see
+	// HTTPClient, and as person.* custom fields otherwise.
+	PersonFieldNames PersonFieldNames
+
+	// PersonFromEntry, when set, is called by the workers to get the person
+	// an entry is about. It takes precedence over the PersonFieldNames and
+	// is reported the same way.
+	PersonFromEntry PersonFromEntry
+
+	// CircuitBreakerThreshold is the number of consecutive failures after
+	// which the hook stops sending to Rollbar for CircuitBreakerCooldown.
+	// Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long the circuit stays open before a
+	// single probe is sent. Defaults to 30 seconds.
+	CircuitBreakerCooldown time.Duration
+
+	// ErrorLogger receives a JSON line for every entry that could not be
+	// delivered, e.g. {"level":"error","hook":"rollbar","msg":"delivery
+	// failed","err":"...","attempt":1}. Defaults to os.Stderr.
+	ErrorLogger io.Writer
+
+	// DebugLogger, when set, logs the internal events of the hook at the
+	// debug level: dropped entries, failed deliveries, retries and shutdown
+	// timeouts. The entries it logs carry InternalField and are never
+	// reported to Rollbar, even if the hook was added to the same logger.
+	DebugLogger log.FieldLogger
+
+	// CodeVersion is reported as the code_version field of every item,
+	// typically the output of `git describe --tags` or the commit SHA set
+	// at build time with -ldflags "-X main.version=$GIT_SHA".
+	CodeVersion string
+	// ServerRoot is the path of the repository on the servers, e.g.
+	// "github.com/org/repo", reported as server.root so that Rollbar links
+	// the frames of the items to their source at CodeVersion. The items
+	// built by the hook, see HTTPClient, also report CodeVersion as their
+	// code_version rather than as a field.
+	ServerRoot string
+
+	// Router, when set, picks the Rollbar project and environment of every
+	// entry. A client is created and cached for each of them.
+	Router Router
+
+	// Metrics, when set, receives the events of the hook as they happen,
+	// see Metrics. Stats returns the same counters for polling.
+	Metrics Metrics
+	// ExpvarPrefix names the expvar.Map the hook publishes its counters in,
+	// served at /debug/vars, see the metrics/expvar package. Hooks created
+	// with a token publish them under the prefix followed by the last 4
+	// characters of the token, the prefix defaulting to "rollrus". Hooks
+	// created with NewHookWithClient only publish them when it is set, under
+	// the prefix itself.
+	ExpvarPrefix string
+
+	// HTTPClient, when set, is used to send the items to Rollbar, e.g. to
+	// go through a proxy or to set a timeout. roll always sends through
+	// http.DefaultClient, so setting HTTPClient, Endpoint, Transformer or
+	// ServerRoot makes the hook build and send the items itself. It is
+	// ignored by NewHookWithClient, except for the clients created for the
+	// Router.
+	HTTPClient *http.Client
+
+	// Endpoint is the URL of Rollbar's item API, or of a compatible
+	// service, to send the items to. Defaults to roll.DefaultEndpoint.
+	Endpoint string
+
+	// Transformer, when set, is called with every item right before it is
+	// sent, as the data of the JSON payload, and returns the item to send.
+	// It is the escape hatch for anything the other options don't cover.
+	Transformer TransformFunc
+
+	// DefaultFields are reported with every entry. Fields set on an entry
+	// take precedence.
+	DefaultFields map[string]string
+	// DisableHostInfo stops the hostname and process ID from being
+	// reported under HostField and PIDField with every entry.
+	DisableHostInfo bool
+
+	// FingerprintFunc sets the fingerprint of entries without a
+	// FingerprintField, which documents how it's reported.
+	FingerprintFunc FingerprintFunc
+
+	// TitleFunc sets the title of entries without a TitleField. The
+	// message is then reported under the message field.
+	TitleFunc TitleFunc
+
+	// PanicFormatter, when set, returns the error reported by ReportPanic
+	// and its variants for a panic value, e.g. to serialize a panic struct
+	// to JSON. Defaults to "panic: %q".
+	PanicFormatter PanicFormatter
+
+	// Hostname overrides the hostname of every item, which otherwise is
+	// os.Hostname(). HostnameFromField names the entry field to read the
+	// hostname from when Hostname is empty. See HostnameKey.
+	Hostname          string
+	HostnameFromField string
+
+	// IncludeMessageAsField reports the message of every entry under the
+	// message field too, as Rollbar may truncate long titles.
+	IncludeMessageAsField bool
+
+	// OnDrop is called with the entries that could not be delivered. Entries
+	// dropped because the buffer was full are only reported for buffers
+	// implementing buffer.DropNotifier.
+	OnDrop OnDropFunc
+
+	// MaxRetries is how many times a failed report is retried, with an
+	// exponential backoff starting at RetryBaseDelay and capped at
+	// RetryMaxDelay. Retries stop when the hook is closed. Defaults to 0.
+	MaxRetries int
+	// RetryBaseDelay defaults to 100 milliseconds.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay defaults to 10 seconds.
+	RetryMaxDelay time.Duration
 }
 
 var defaultTriggerLevels = []log.Level{
@@ -34,17 +291,86 @@ var defaultTriggerLevels = []log.Level{
 
 var defaultNumWorkers = 8 * runtime.NumCPU()
 var defaultBufferSize = 2 * defaultNumWorkers
+var defaultWorkerIdleTimeout = 30 * time.Second
+var defaultCloseTimeout = 5 * time.Second
+var defaultMaxFlattenDepth = 5
+var flushInterval = 10 * time.Millisecond
+var defaultCircuitBreakerCooldown = 30 * time.Second
+var defaultRetryBaseDelay = 100 * time.Millisecond
+var defaultRetryMaxDelay = 10 * time.Second
+
+// RollClient is the subset of roll.Client the hook reports through. Any
+// roll.Client is a RollClient.
+type RollClient interface {
+	Critical(err error, custom map[string]string) (uuid string, e error)
+	CriticalStack(err error, ptrs []uintptr, custom map[string]string) (uuid string, e error)
+	Error(err error, custom map[string]string) (uuid string, e error)
+	ErrorStack(err error, ptrs []uintptr, custom map[string]string) (uuid string, e error)
+	Warning(err error, custom map[string]string) (uuid string, e error)
+	WarningStack(err error, ptrs []uintptr, custom map[string]string) (uuid string, e error)
+	Info(msg string, custom map[string]string) (uuid string, e error)
+	Debug(msg string, custom map[string]string) (uuid string, e error)
+}
 
 // Hook wrapper for the rollbar Client
 // May be used as a rollbar client itself
 type Hook struct {
 	roll.Client
 	triggers []log.Level
+	levels   *levelSet
 	entries  buffer.Buffer
 	closed   chan struct{}
-	once     *sync.Once
-	wg       *sync.WaitGroup
-	pool     chan chan job
+	// closing is closed as soon as Close is called, to stop the retries.
+	closing   chan struct{}
+	once      *sync.Once
+	abort     chan struct{}
+	abortOnce *sync.Once
+	wg        *sync.WaitGroup
+	pool      chan chan job
+	acker     buffer.Acker
+	scaler    *scaler
+	sync      bool
+	forget    bool
+	// forgotten bounds the goroutines of FireAndForget, see FireModeSync.
+	forgotten *forgotten
+	// noop makes Fire return right away, see NewNoopHook.
+	noop     bool
+	counters *counters
+	limiter  Limiter
+	sampler  *sampler
+	filter   func(*log.Entry) bool
+	onDrop   OnDropFunc
+	dedupe   *deduper
+	stacks   bool
+	errorKey string
+	causes   bool
+	otel     bool
+	// fields are added to every entry, see WithFields.
+	fields log.Fields
+
+	severities     map[log.Level]string
+	scrubber       *scrubber
+	fieldList      *fieldList
+	router         Router
+	clients        *clientCache
+	beforeSend     BeforeSendFunc
+	person         PersonFieldNames
+	personFunc     PersonFromEntry
+	fingerprint    FingerprintFunc
+	title          TitleFunc
+	panicFormatter PanicFormatter
+	hostname       string
+	hostnameField  string
+	withMessage    bool
+	breaker        *breaker
+	debugLog       *debugLogger
+	errorLog       *errorLogger
+	codeVersion    *atomic.Value
+	retry          retryPolicy
+	defaults       map[string]string
+	flattenDepth   int
+	richFields     bool
+	closeTimeout   time.Duration
 }
 
 // Setup a new hook with default reporting levels, useful for adding to
@@ -53,36 +379,260 @@ func NewHook(token string, env string) *Hook {
 	return NewHookForLevels(token, env, RollrusConfig{})
 }
 
+// Setup a new hook with default reporting levels that sends every entry
+// before Fire returns, see SyncFire. Useful for CLIs and short jobs.
+func NewSyncHook(token string, env string) *Hook {
+	return NewHookForLevels(token, env, RollrusConfig{SyncFire: true})
+}
+
+// Setup a new hook reporting warnings as well as the default reporting
+// levels. The levels can be changed later with SetLevels.
+func NewHookWithWarnings(token string, env string) *Hook {
+	levels := append([]log.Level{log.WarnLevel}, defaultTriggerLevels...)
+	return NewHookForLevels(token, env, RollrusConfig{LogLevels: levels})
+}
+
 // Setup a new hook with specified reporting levels, useful for adding to
 // your own logger instance.
 func NewHookForLevels(token string, env string, config RollrusConfig) *Hook {
+	return newHook(newRollClient(token, env, config), token, config)
+}
+
+// NewHookWithContext works like NewHookForLevels, but closes the hook when ctx
+// is done, as Close does. Calling Close as well is safe.
+func NewHookWithContext(ctx context.Context, token string, env string, config RollrusConfig) *Hook {
+	h := NewHookForLevels(token, env, config)
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.Close()
+		case <-h.closed:
+		}
+	}()
+	return h
+}
+
+// NewHookWithClient sets up a new hook reporting through client instead of
+// creating one with roll.New. It may be a shared roll.Client, for instance one
+// with a custom endpoint or timeout, or a fake recording the calls in tests.
+func NewHookWithClient(client RollClient, config RollrusConfig) *Hook {
+	return newHook(client, "", config)
+}
+
+// newHook sets up a new hook reporting through client, the token of which is
+// empty when unknown.
+func newHook(client RollClient, token string, config RollrusConfig) *Hook {
+	if config.Enabled != nil && !config.Enabled() {
+		return newInactiveHook()
+	}
+
+	switch {
+	case token != "":
+		prefix := config.ExpvarPrefix
+		if prefix == "" {
+			prefix = "rollrus"
+		}
+		config.Metrics = joinMetrics(config.Metrics, expvarmetrics.NewWithPrefix(prefix, token))
+	case config.ExpvarPrefix != "":
+		config.Metrics = joinMetrics(config.Metrics, expvarmetrics.New(config.ExpvarPrefix))
+	}
+
 	if len(config.LogLevels) == 0 {
 		config.LogLevels = defaultTriggerLevels
 	}
 
+	if config.FireMode == FireModeSync {
+		config.SyncFire = true
+	}
+
 	if config.Buffer == nil {
-		config.Buffer = channel.NewBuffer(defaultBufferSize)
+		if config.FireMode == FireModeFireAndForget && config.BufferOverflowPolicy == buffer.Block {
+			config.BufferOverflowPolicy = buffer.DropNewest
+		}
+		config.Buffer = channel.NewBufferWithPolicy(defaultBufferSize, config.BufferOverflowPolicy)
 	}
 
 	if config.NumWorkers == 0 {
 		config.NumWorkers = defaultNumWorkers
 	}
 
+	if config.CloseTimeout == 0 {
+		config.CloseTimeout = defaultCloseTimeout
+	}
+
+	if config.RateBurst == 0 {
+		config.RateBurst = 1
+	}
+
+	if config.ScrubFields == nil {
+		config.ScrubFields = DefaultScrubFields
+	}
+
+	if config.PersonFieldNames == (PersonFieldNames{}) {
+		config.PersonFieldNames = defaultPersonFieldNames
+	}
+
+	if config.CircuitBreakerCooldown == 0 {
+		config.CircuitBreakerCooldown = defaultCircuitBreakerCooldown
+	}
+
+	if config.RetryBaseDelay == 0 {
+		config.RetryBaseDelay = defaultRetryBaseDelay
+	}
+
+	if config.RetryMaxDelay == 0 {
+		config.RetryMaxDelay = defaultRetryMaxDelay
+	}
+
+	if config.StackTraceField == "" {
+		config.StackTraceField = log.ErrorKey
+	}
+
+	if config.MaxFlattenDepth == 0 {
+		config.MaxFlattenDepth = defaultMaxFlattenDepth
+	}
+
 	numWorkers := config.NumWorkers
+	var sc *scaler
+	if config.MaxWorkers > 0 {
+		if config.MinWorkers < 1 {
+			config.MinWorkers = 1
+		}
+		if config.MaxWorkers < config.MinWorkers {
+			config.MaxWorkers = config.MinWorkers
+		}
+		if config.WorkerIdleTimeout == 0 {
+			config.WorkerIdleTimeout = defaultWorkerIdleTimeout
+		}
+
+		// The pool holds the channels of up to MaxWorkers workers.
+		numWorkers = config.MaxWorkers
+		sc = &scaler{
+			min:         int32(config.MinWorkers),
+			max:         int32(config.MaxWorkers),
+			workers:     int32(config.MinWorkers),
+			idleTimeout: config.WorkerIdleTimeout,
+		}
+	}
+	debug := newDebugLogger(config.DebugLogger)
 	h := &Hook{
-		Client:   roll.New(token, env),
-		triggers: config.LogLevels,
-		closed:   make(chan struct{}),
-		entries:  config.Buffer,
-		once:     new(sync.Once),
-		pool:     make(chan chan job, numWorkers),
-		wg:       new(sync.WaitGroup),
+		Client:    client,
+		triggers:  config.LogLevels,
+		levels:    new(levelSet),
+		closed:    make(chan struct{}),
+		closing:   make(chan struct{}),
+		entries:   config.Buffer,
+		once:      new(sync.Once),
+		abort:     make(chan struct{}),
+		abortOnce: new(sync.Once),
+		pool:      make(chan chan job, numWorkers),
+		wg:        new(sync.WaitGroup),
+		sync:      config.SyncFire,
+		forget:    config.FireMode == FireModeFireAndForget,
+		forgotten: &forgotten{max: config.NumWorkers},
+		scaler:    sc,
+		stacks:    !config.DisableStackCapture,
+		errorKey:  config.StackTraceField,
+		causes:    config.WrapCauses,
+		otel:      config.WithOTelContext,
+		filter:    config.Filter,
+		onDrop:    config.OnDrop,
+
+		closeTimeout:   config.CloseTimeout,
+		severities:     config.LevelSeverityMap,
+		scrubber:       newScrubber(config.ScrubFields, config.ScrubFunc),
+		fieldList:      newFieldList(config.FieldWhitelist, config.FieldBlacklist),
+		beforeSend:     config.BeforeSend,
+		person:         config.PersonFieldNames,
+		personFunc:     config.PersonFromEntry,
+		fingerprint:    config.FingerprintFunc,
+		title:          config.TitleFunc,
+		panicFormatter: config.PanicFormatter,
+		hostname:       config.Hostname,
+		hostnameField:  config.HostnameFromField,
+		withMessage:    config.IncludeMessageAsField,
+		breaker:        newBreaker(config.CircuitBreakerThreshold, config.CircuitBreakerCooldown),
+		debugLog:       debug,
+		errorLog:       newErrorLogger(config.ErrorLogger, debug),
+		codeVersion:    new(atomic.Value),
+		retry: retryPolicy{
+			max:       config.MaxRetries,
+			baseDelay: config.RetryBaseDelay,
+			maxDelay:  config.RetryMaxDelay,
+		},
+	}
+
+	if len(config.DefaultFields) > 0 || !config.DisableHostInfo {
+		h.defaults = make(map[string]string, len(config.DefaultFields)+2)
+		if !config.DisableHostInfo {
+			setHostInfo(h.defaults)
+		}
+		for k, v := range config.DefaultFields {
+			h.defaults[k] = v
+		}
+	}
+
+	if a, ok := config.Buffer.(buffer.Acker); ok && !h.sync {
+		a.Acknowledge()
+		h.acker = a
+	}
+
+	if n, ok := config.Buffer.(buffer.DropNotifier); ok && (h.onDrop != nil || h.debugLog != nil || config.Metrics != nil) {
+		n.NotifyDrop(func(entry *log.Entry) {
+			h.counters.m().IncDropped()
+			h.dropped(entry, ErrBufferFull)
+		})
+	}
+
+	if config.Router != nil {
+		h.router = config.Router
+		h.clients = newClientCache(config)
+	}
+
+	if config.DedupeWindow > 0 {
+		key := config.DedupeKey
+		if key == nil {
+			key = defaultDedupeKey(config.FingerprintFunc)
+		}
+		h.dedupe = newDeduper(config.DedupeWindow, key, config.DedupeMaxKeys, func(entry *log.Entry) {
+			if err := h.enqueue(entry); err != nil {
+				h.errorLog.log("delivery failed", err, 0)
+			}
+		})
+	}
+
+	h.sampler = newSampler(config.SampleRate, config.SampleRates)
+	h.SetCodeVersion(config.CodeVersion)
+
+	h.counters = newCounters(config.Metrics, h.OverflowDropCount)
+
+	if l, ok := config.Buffer.(buffer.Lener); ok {
+		// Entries replayed by the buffer haven't gone through Fire.
+		h.counters.pending = int64(l.Len())
+	}
+
+	if config.Limiter == nil && config.RateLimit > 0 {
+		config.Limiter = NewRateLimiter(config.RateLimit, config.RateBurst)
+	}
+	h.limiter = config.Limiter
+
+	if config.FlattenFields {
+		h.flattenDepth = config.MaxFlattenDepth
+	} else {
+		h.richFields = config.RichFields
+	}
+
+	if h.sync {
+		return h
+	}
+
+	if h.scaler != nil {
+		numWorkers = config.MinWorkers
+		go h.retireIdleWorkers()
 	}
 
 	for i := 0; i < numWorkers; i++ {
-		h.wg.Add(1)
-		worker := newWorker(h.pool, h.closed, h.wg)
-		worker.Work()
+		h.startWorker()
 	}
 
 	go h.dispatch()
@@ -94,22 +644,34 @@ func NewHookForLevels(token string, env string, config RollrusConfig) *Hook {
 // hook is added with the environment set to env. The log formatter is set to a
 // TextFormatter with timestamps disabled, which is suitable for use on Heroku.
 func SetupLogging(token, env string) io.Closer {
-	return setupLogging(token, env, RollrusConfig{})
+	return setupLogging(log.StandardLogger(), token, env, RollrusConfig{})
 }
 
 // SetupLoggingForLevels works like SetupLogging, but allows you to
 // set the levels on which to trigger this hook.
 func SetupLoggingForLevels(token, env string, config RollrusConfig) io.Closer {
-	return setupLogging(token, env, config)
+	return setupLogging(log.StandardLogger(), token, env, config)
+}
+
+// SetupLoggingForLogger works like SetupLogging, but sets up logger instead
+// of the standard logger.
+func SetupLoggingForLogger(logger *log.Logger, token, env string) io.Closer {
+	return setupLogging(logger, token, env, RollrusConfig{})
 }
 
-func setupLogging(token, env string, config RollrusConfig) io.Closer {
-	log.SetFormatter(&log.TextFormatter{DisableTimestamp: true})
+// SetupLoggingForLevelsForLogger works like SetupLoggingForLevels, but sets up
+// logger instead of the standard logger.
+func SetupLoggingForLevelsForLogger(logger *log.Logger, token, env string, config RollrusConfig) io.Closer {
+	return setupLogging(logger, token, env, config)
+}
+
+func setupLogging(logger *log.Logger, token, env string, config RollrusConfig) io.Closer {
+	logger.SetFormatter(&log.TextFormatter{DisableTimestamp: true})
 
 	var closer io.Closer
 	if token != "" {
 		h := NewHookForLevels(token, env, config)
-		log.AddHook(h)
+		logger.AddHook(h)
 		closer = h
 	} else {
 		closer = noopCloser{}
@@ -123,73 +685,420 @@ func setupLogging(token, env string, config RollrusConfig) io.Closer {
 // error to stderr.
 func (r *Hook) ReportPanic() {
 	if p := recover(); p != nil {
-		if _, err := r.Client.Critical(fmt.Errorf("panic: %q", p), nil); err != nil {
-			fmt.Fprintf(os.Stderr, "reporting_panic=false err=%q\n", err)
-		}
-		panic(p)
+		r.reportPanic(nil, nil, p)
 	}
 }
 
+// ReportPanicWithContext works like ReportPanic, but reports the trace and
+// request IDs, and the request stored by WithRequest, carried by ctx along
+// with the panic.
+func (r *Hook) ReportPanicWithContext(ctx context.Context) {
+	if p := recover(); p != nil {
+		r.reportPanic(ctx, nil, p)
+	}
+}
+
+// ReportPanicWithFields works like ReportPanic, but reports fields along with
+// the panic, e.g. the endpoint or the user a handler was serving.
+func (r *Hook) ReportPanicWithFields(fields log.Fields) {
+	if p := recover(); p != nil {
+		r.reportPanic(nil, fields, p)
+	}
+}
+
+// newPanicHook returns the hook the package level ReportPanic functions report
+// through: a synchronous hook with the default configuration, so that the
+// fields are scrubbed.
+func newPanicHook(token, env string) *Hook {
+	return NewHookWithClient(roll.New(token, env), RollrusConfig{SyncFire: true})
+}
+
 // ReportPanic attempts to report the panic to rollbar if the token is set
 func ReportPanic(token, env string) {
 	if token != "" {
-		h := &Hook{Client: roll.New(token, env)}
-		h.ReportPanic()
+		if p := recover(); p != nil {
+			h := newPanicHook(token, env)
+			h.reportPanic(nil, nil, p)
+		}
+	}
+}
+
+// ReportPanicWithFields attempts to report the panic to rollbar, along with
+// fields, if the token is set
+func ReportPanicWithFields(token, env string, fields log.Fields) {
+	if token != "" {
+		if p := recover(); p != nil {
+			h := newPanicHook(token, env)
+			h.reportPanic(nil, fields, p)
+		}
+	}
+}
+
+// ReportPanicWithContext attempts to report the panic to rollbar, along with
+// the trace and request IDs carried by ctx, if the token is set
+func ReportPanicWithContext(ctx context.Context, token, env string) {
+	if token != "" {
+		if p := recover(); p != nil {
+			h := newPanicHook(token, env)
+			h.reportPanic(ctx, nil, p)
+		}
 	}
 }
 
+// PanicFormatter returns the error reported for the panic value p. Its message
+// is the title of the item and its type is reported as the class.
+type PanicFormatter func(p interface{}) error
+
+// defaultPanicFormatter reports p as "panic: " followed by its quoted value.
+func defaultPanicFormatter(p interface{}) error {
+	return fmt.Errorf("panic: %q", p)
+}
+
+// reportPanic reports p and then re-panics. recover only works when called
+// directly by the deferred function, so callers must recover p themselves.
+func (r *Hook) reportPanic(ctx context.Context, fields log.Fields, p interface{}) {
+	// The request and trace fields go through the same filtering and
+	// scrubbing as the fields, which take precedence.
+	data := make(log.Fields, len(fields))
+	if ctx != nil {
+		if req := requestFromContext(ctx); req != nil {
+			for k, v := range requestFields(req) {
+				data[k] = v
+			}
+		}
+		for k, v := range TraceFields(ctx) {
+			data[k] = v
+		}
+	}
+	for k, v := range fields {
+		data[k] = v
+	}
+
+	j := r.newJob(nil)
+	m, _ := j.customFields(data)
+	j.setDefaults(m)
+
+	format := r.panicFormatter
+	if format == nil {
+		format = defaultPanicFormatter
+	}
+
+	if _, err := r.Client.CriticalStack(format(p), panicStack(), m); err != nil {
+		r.errorLog.log("panic report failed", err, 0)
+	}
+	panic(p)
+}
+
+// ReportMessage reports msg with fields at level, whether or not the hook
+// handles level, for code without a logrus.Logger at hand. The item goes
+// through the same filters, buffer and workers as entries fired by logrus, so
+// unless the hook was configured with SyncFire it doesn't wait for Rollbar.
+func (r *Hook) ReportMessage(level log.Level, msg string, fields log.Fields) error {
+	data := make(log.Fields, len(fields))
+	for k, v := range fields {
+		data[k] = v
+	}
+
+	return r.Fire(&log.Entry{
+		Logger:  log.StandardLogger(),
+		Data:    data,
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+	})
+}
+
 // Fire the hook. This is called by Logrus for entries that match the levels
-// returned by Levels(). See below.
-func (r *Hook) Fire(entry *log.Entry) (err error) {
+// returned by Levels(). See below. When the hook was configured with SyncFire
+// the entry is sent before Fire returns and any delivery error is returned.
+// The logging call then waits for the Rollbar API, retries included, so this
+// is best kept to CLIs and jobs; servers should stay on the default async
+// delivery.
+func (r *Hook) Fire(entry *log.Entry) error {
+	if r.noop {
+		return nil
+	}
+	if r.forget {
+		return r.FireAndForget(entry)
+	}
+	return r.fire(entry)
+}
+
+func (r *Hook) fire(entry *log.Entry) error {
+	if internal(entry) {
+		return nil
+	}
+	r.counters.fire()
+
+	if r.filter != nil && !r.filter(entry) {
+		r.counters.reject()
+		return nil
+	}
+
+	if !r.sampler.keep(entry) {
+		r.counters.reject()
+		return nil
+	}
+
+	if r.limiter != nil && !r.limiter.Allow(entry) {
+		r.counters.reject()
+		return nil
+	}
+
+	entry = withFields(entry, r.fields)
+	entry = withRequestFields(entry)
+	if r.stacks {
+		entry = withStack(entry)
+	}
+
+	if r.dedupe != nil && r.dedupe.suppress(entry) {
+		return nil
+	}
+
+	return r.enqueue(entry)
+}
+
+// enqueue sends entry when the hook is synchronous, or buffers it otherwise.
+func (r *Hook) enqueue(entry *log.Entry) error {
+	if r.sync {
+		start := time.Now()
+		err := r.newJob(entry).deliver()
+		if skipped(err) {
+			r.counters.reject()
+			if err == ErrCircuitOpen {
+				r.dropped(entry, err)
+				return err
+			}
+			return nil
+		}
+		if err != nil {
+			r.dropped(entry, err)
+		}
+		r.counters.m().ObserveLatency(time.Since(start))
+		r.counters.sent(err)
+		return err
+	}
+
+	r.counters.push()
 	r.entries.Push(entry)
 	return nil
 }
 
 func (r *Hook) dispatch() {
+	defer close(r.closed)
+
 	for r.entries.Next() {
 		entry := r.entries.Value()
-		jobChannel := <-r.pool
-		jobChannel <- job{
-			client: r.Client,
-			entry:  entry,
+
+		jobChannel, ok := r.nextWorker()
+		if !ok {
+			r.counters.drop()
+			r.dropped(entry, ErrShutdown)
+			for r.entries.Next() {
+				r.counters.drop()
+				r.dropped(r.entries.Value(), ErrShutdown)
+			}
+			return
+		}
+
+		j := r.newJob(entry)
+		if r.acker != nil {
+			j.acker, j.ackID = r.acker, r.acker.ID()
+		}
+		jobChannel <- j
+	}
+}
+
+// Flush blocks until every entry fired so far has been sent to Rollbar or
+// dropped. Unlike Close the hook keeps accepting entries.
+func (r *Hook) Flush() error {
+	return r.FlushWithContext(context.Background())
+}
+
+// FlushWithContext works like Flush, but gives up waiting when ctx is done.
+// The entries still buffered at that point are sent later on.
+func (r *Hook) FlushWithContext(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for !r.Idle() {
+		select {
+		case <-ctx.Done():
+			pending := r.Stats().Buffered
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("rollrus: flush timed out, %d entries pending", pending)
+			}
+			return fmt.Errorf("rollrus: flush canceled, %d entries pending", pending)
+		case <-ticker.C:
 		}
 	}
+	return nil
 }
 
+// Close stops the hook from accepting entries and waits for the buffered
+// entries to be sent to Rollbar, giving up after the configured CloseTimeout.
 func (r *Hook) Close() error {
+	return r.CloseWithTimeout(r.closeTimeout)
+}
+
+// CloseWithTimeout works like Close, but gives up waiting for the buffered
+// entries to be sent after d. A d of zero waits indefinitely.
+func (r *Hook) CloseWithTimeout(d time.Duration) error {
+	ctx := context.Background()
+	if d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	return r.CloseWithContext(ctx)
+}
+
+// CloseWithContext works like Close, but waits for the buffered entries to be
+// sent until ctx is done. Entries that haven't been handed to a worker by then
+// are dropped.
+func (r *Hook) CloseWithContext(ctx context.Context) error {
 	r.once.Do(func() {
-		close(r.closed)
+		close(r.closing)
+		r.forgotten.close()
+		if r.dedupe != nil {
+			r.dedupe.stop()
+		}
 		r.entries.Close()
+		if r.sync {
+			// There is no dispatcher to close it once the entries are sent.
+			go func() {
+				r.wg.Wait()
+				close(r.closed)
+			}()
+		}
 	})
 
-	r.wg.Wait()
-	return nil
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.abortOnce.Do(func() {
+			close(r.abort)
+		})
+
+		dropped := atomic.LoadInt64(&r.counters.pending)
+		r.debugLog.log("shutdown interrupted", log.Fields{log.ErrorKey: ctx.Err(), "dropped": dropped})
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("rollrus: shutdown timed out, %d entries dropped", dropped)
+		}
+		return fmt.Errorf("rollrus: shutdown canceled, %d entries dropped", dropped)
+	}
+}
+
+// SetCodeVersion changes the code version reported with the following items,
+// e.g. after a hot reload. An empty v stops reporting it.
+func (r *Hook) SetCodeVersion(v string) {
+	r.codeVersion.Store(v)
+}
+
+// levelSet holds the levels set with SetLevels, which override the levels the
+// hook was created with. Hooks hold it by pointer as WithFields copies them.
+type levelSet struct {
+	mu     sync.RWMutex
+	levels []log.Level
 }
 
 // Levels returns the logrus log levels that this hook handles
 func (r *Hook) Levels() []log.Level {
+	if r.levels != nil {
+		r.levels.mu.RLock()
+		defer r.levels.mu.RUnlock()
+		if r.levels.levels != nil {
+			return r.levels.levels
+		}
+	}
+
 	if r.triggers == nil {
 		return defaultTriggerLevels
 	}
 	return r.triggers
 }
 
+// SetLevels changes the levels the hook handles, e.g. to report warnings too
+// during an incident. An empty levels handles no level. MultiHook, SlogHandler
+// and the adapters pick up the change immediately, but logrus reads Levels
+// only when the hook is added to a logger, so re-add it afterwards:
+//
+//	hook.SetLevels(append(hook.Levels(), logrus.WarnLevel))
+//	logger.ReplaceHooks(make(logrus.LevelHooks))
+//	logger.AddHook(hook)
+func (r *Hook) SetLevels(levels []log.Level) {
+	if r.levels == nil {
+		r.levels = new(levelSet)
+	}
+
+	r.levels.mu.Lock()
+	defer r.levels.mu.Unlock()
+	r.levels.levels = append([]log.Level{}, levels...)
+}
+
 // convertFields converts from log.Fields to map[string]string so that we can
 // report extra fields to Rollbar
 func convertFields(fields log.Fields) map[string]string {
 	m := make(map[string]string)
 	for k, v := range fields {
-		switch t := v.(type) {
-		case time.Time:
-			m[k] = t.Format(time.RFC3339)
-		default:
-			if s, ok := v.(fmt.Stringer); ok {
-				m[k] = s.String()
-			} else {
-				m[k] = fmt.Sprintf("%+v", t)
-			}
-		}
+		setField(m, k, v)
 	}
 
 	return m
 }
+
+// setField sets k to the formatted v, errors are expanded to include their
+// type, cause and stack trace.
+func setField(m map[string]string, k string, v interface{}) {
+	if err, ok := v.(error); ok {
+		setError(m, k, err)
+		return
+	}
+	m[k] = formatValue(v)
+}
+
+// formatValue formats a single field value for Rollbar. It tries, in order:
+// time.Time, byte slices, error, fmt.Stringer and encoding.TextMarshaler,
+// before falling back to %+v, which uses fmt.Formatter when implemented.
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case []byte:
+		return formatBytes(t)
+	case json.RawMessage:
+		return formatBytes(t)
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	case encoding.TextMarshaler:
+		if text, err := t.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// formatBytes returns b as a string when it's printable UTF-8 text, and base64
+// encoded otherwise.
+func formatBytes(b []byte) string {
+	if !utf8.Valid(b) {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return base64.StdEncoding.EncodeToString(b)
+		}
+	}
+	return string(b)
+}
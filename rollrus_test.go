@@ -1,11 +1,21 @@
 package rollrus
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/channel"
+	"github.com/benjamindow/rollrus/buffer/disk"
 	"github.com/sirupsen/logrus"
 	"github.com/stvp/roll"
 )
@@ -32,6 +42,26 @@ func ExampleNewHook() {
 	log.Panic("Boom.")
 }
 
+func TestSetupLoggingForLogger(t *testing.T) {
+	logger := logrus.New()
+	standard := len(logrus.StandardLogger().Hooks[logrus.ErrorLevel])
+
+	closer := SetupLoggingForLogger(logger, "some-long-token", "test")
+	defer closer.Close()
+
+	if n := len(logger.Hooks[logrus.ErrorLevel]); n != 1 {
+		t.Fatalf("Expected the hook to be added to the logger, got %d hooks", n)
+	}
+
+	if n := len(logrus.StandardLogger().Hooks[logrus.ErrorLevel]); n != standard {
+		t.Fatalf("Expected the standard logger to be left alone, got %d hooks", n)
+	}
+
+	if _, ok := logger.Formatter.(*logrus.TextFormatter); !ok {
+		t.Fatalf("Expected a TextFormatter, got %T", logger.Formatter)
+	}
+}
+
 func TestIntConversion(t *testing.T) {
 	i := make(logrus.Fields)
 	i["test"] = 5
@@ -80,6 +110,53 @@ func TestStringConversion(t *testing.T) {
 	}
 }
 
+func TestBytesConversion(t *testing.T) {
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{[]byte("payload text\n"), "payload text\n"},
+		{json.RawMessage(`{"id":1}`), `{"id":1}`},
+		{[]byte{0xff, 0x00, 0x01}, "/wAB"},
+		{[]byte("\x00\x01"), "AAE="},
+		{[]byte{}, ""},
+	} {
+		r := convertFields(logrus.Fields{"test": test.value})
+		if r["test"] != test.expected {
+			t.Errorf("Expected %v to be converted to %q, got %q", test.value, test.expected, r["test"])
+		}
+	}
+}
+
+type textValue string
+
+func (v textValue) MarshalText() ([]byte, error) {
+	return []byte("text:" + string(v)), nil
+}
+
+type formattedValue struct{}
+
+func (formattedValue) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, "formatted")
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{textValue("a"), "text:a"},
+		{formattedValue{}, "formatted"},
+		{net.ParseIP("127.0.0.1"), "127.0.0.1"},
+		{errors.New("failed"), "failed"},
+		{struct{ A int }{1}, "{A:1}"},
+	} {
+		if got := formatValue(test.value); got != test.expected {
+			t.Errorf("Expected %#v to be formatted as %q, got %q", test.value, test.expected, got)
+		}
+	}
+}
+
 func TestTimeConversion(t *testing.T) {
 	now := time.Now()
 	i := make(logrus.Fields)
@@ -109,4 +186,567 @@ func TestTriggerLevels(t *testing.T) {
 	if !reflect.DeepEqual(underTest.Levels(), newLevels) {
 		t.Fatal("Expected Levels() to return newLevels")
 	}
-}
\ No newline at end of file
+}
+
+type fakeItem struct {
+	severity string
+	msg      string
+	custom   map[string]string
+	stack    []uintptr
+}
+
+// fakeClient records the items that would have been sent to Rollbar.
+type fakeClient struct {
+	roll.Client
+
+	// delay simulates a slow Rollbar endpoint.
+	delay time.Duration
+	// err is returned from every call.
+	err error
+
+	mu    sync.Mutex
+	items []fakeItem
+}
+
+func (c *fakeClient) record(severity, msg string, custom map[string]string) (string, error) {
+	return c.recordStack(severity, msg, nil, custom)
+}
+
+func (c *fakeClient) recordStack(severity, msg string, stack []uintptr, custom map[string]string) (string, error) {
+	time.Sleep(c.delay)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, fakeItem{severity: severity, msg: msg, custom: custom, stack: stack})
+	return "", c.err
+}
+
+func (c *fakeClient) Critical(err error, custom map[string]string) (string, error) {
+	return c.record(SeverityCritical, err.Error(), custom)
+}
+
+func (c *fakeClient) CriticalStack(err error, stack []uintptr, custom map[string]string) (string, error) {
+	return c.recordStack(SeverityCritical, err.Error(), stack, custom)
+}
+
+func (c *fakeClient) Error(err error, custom map[string]string) (string, error) {
+	return c.record(SeverityError, err.Error(), custom)
+}
+
+func (c *fakeClient) ErrorStack(err error, stack []uintptr, custom map[string]string) (string, error) {
+	return c.recordStack(SeverityError, err.Error(), stack, custom)
+}
+
+func (c *fakeClient) Warning(err error, custom map[string]string) (string, error) {
+	return c.record(SeverityWarning, err.Error(), custom)
+}
+
+func (c *fakeClient) WarningStack(err error, stack []uintptr, custom map[string]string) (string, error) {
+	return c.recordStack(SeverityWarning, err.Error(), stack, custom)
+}
+
+func (c *fakeClient) Info(msg string, custom map[string]string) (string, error) {
+	return c.record(SeverityInfo, msg, custom)
+}
+
+func (c *fakeClient) Debug(msg string, custom map[string]string) (string, error) {
+	return c.record(SeverityDebug, msg, custom)
+}
+
+func (c *fakeClient) Items() []fakeItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]fakeItem(nil), c.items...)
+}
+
+func TestSyncFire(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("sync error")
+
+	items := client.Items()
+	if len(items) != 1 || items[0].msg != "sync error" {
+		t.Fatalf("Expected entry to be sent before Fire returned, got %v", items)
+	}
+
+	log.Info("not reported")
+
+	if n := len(client.Items()); n != 1 {
+		t.Fatalf("Expected Info entry to be ignored, got %d items", n)
+	}
+}
+
+func TestNewSyncHook(t *testing.T) {
+	hook := NewSyncHook("", "test")
+	defer hook.Close()
+
+	if !hook.sync {
+		t.Fatal("Expected NewSyncHook to fire synchronously")
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	ctx := context.WithValue(context.Background(), RequestIDKey, "abc123")
+	ctx = context.WithValue(ctx, TraceIDKey, "trace")
+	log.WithContext(ctx).WithField("trace_id", "field").Error("with context")
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	if v := items[0].custom["request_id"]; v != "abc123" {
+		t.Fatal("Expected request_id from the context, but instead it is: ", v)
+	}
+
+	if v := items[0].custom["trace_id"]; v != "field" {
+		t.Fatal("Expected trace_id field to take precedence, but instead it is: ", v)
+	}
+}
+
+func TestCloseDrainsBuffer(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 2})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 10; i++ {
+		log.Error("async error")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 10 {
+		t.Fatalf("Expected all entries to be sent on Close, got %d", n)
+	}
+}
+
+func TestCloseWithTimeout(t *testing.T) {
+	client := &fakeClient{delay: time.Second}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 1})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("slow error")
+
+	start := time.Now()
+	err := hook.CloseWithTimeout(10 * time.Millisecond)
+	if err == nil {
+		t.Fatal("Expected CloseWithTimeout to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatal("Expected CloseWithTimeout to return after the deadline, took ", elapsed)
+	}
+
+	if err.Error() != "rollrus: shutdown timed out, 1 entries dropped" {
+		t.Fatal("Unexpected error: ", err)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	client := &fakeClient{delay: time.Second}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers:   1,
+		CloseTimeout: 10 * time.Millisecond,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 3; i++ {
+		log.Error("slow error")
+	}
+
+	start := time.Now()
+	if err := hook.Close(); err == nil {
+		t.Fatal("Expected Close to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatal("Expected Close to return after the CloseTimeout, took ", elapsed)
+	}
+}
+
+func TestCloseWithContext(t *testing.T) {
+	client := &fakeClient{delay: time.Second}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 1})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("slow error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := hook.CloseWithContext(ctx)
+	if err == nil || err.Error() != "rollrus: shutdown canceled, 1 entries dropped" {
+		t.Fatal("Unexpected error: ", err)
+	}
+}
+
+func TestNewHookWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	hook := NewHookWithContext(ctx, "some-long-token", "test", RollrusConfig{})
+
+	cancel()
+
+	select {
+	case <-hook.closed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the hook to be closed when the context is canceled")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected closing again to succeed, got: ", err)
+	}
+}
+
+func TestNewHookWithContextSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hook := NewHookWithContext(ctx, "some-long-token", "test", RollrusConfig{SyncFire: true})
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	select {
+	case <-hook.closed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Close to stop watching the context")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	client := &fakeClient{delay: 10 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 2})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 3; i++ {
+		log.Error("flushed")
+	}
+
+	if err := hook.Flush(); err != nil {
+		t.Fatal("Expected Flush to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 3 {
+		t.Fatalf("Expected 3 entries to be sent after Flush, got %d", n)
+	}
+
+	log.Error("after flush")
+
+	if err := hook.Flush(); err != nil {
+		t.Fatal("Expected Flush to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 4 {
+		t.Fatalf("Expected the hook to keep working after Flush, got %d entries", n)
+	}
+}
+
+func TestFlushWithContext(t *testing.T) {
+	client := &fakeClient{delay: 50 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 1})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 3; i++ {
+		log.Error("flushed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := hook.FlushWithContext(ctx); err == nil {
+		t.Fatal("Expected FlushWithContext to time out")
+	}
+
+	if err := hook.FlushWithContext(context.Background()); err != nil {
+		t.Fatal("Expected FlushWithContext to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 3 {
+		t.Fatalf("Expected the entries pending after the timeout to be sent, got %d", n)
+	}
+
+	log.Error("after flush")
+	hook.Flush()
+
+	if n := len(client.Items()); n != 4 {
+		t.Fatalf("Expected the hook to keep working after FlushWithContext, got %d entries", n)
+	}
+}
+
+// panickingClient panics when reporting an error titled "boom".
+type panickingClient struct {
+	*fakeClient
+}
+
+func (c panickingClient) ErrorStack(err error, stack []uintptr, custom map[string]string) (string, error) {
+	if err.Error() == "boom" {
+		panic("client bug")
+	}
+	return c.fakeClient.ErrorStack(err, stack, custom)
+}
+
+func TestWorkerRecoversFromPanic(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(panickingClient{client}, RollrusConfig{
+		NumWorkers:  1,
+		ErrorLogger: ioutil.Discard,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("boom")
+	log.Error("after")
+	log.Error("boom")
+	log.Error("after")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 2 {
+		t.Fatalf("Expected the worker to keep sending after a panic, got %d items", n)
+	}
+
+	if stats := hook.Stats(); stats.Sent != 2 || stats.Failed != 2 {
+		t.Fatalf("Expected 2 sent and 2 failed entries, got %+v", stats)
+	}
+}
+
+func TestCodeVersion(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true, CodeVersion: "v1.0.0"})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("first")
+	hook.SetCodeVersion("v1.0.1")
+	log.Error("second")
+	hook.SetCodeVersion("")
+	log.Error("third")
+
+	items := client.Items()
+	if v := items[0].custom["code_version"]; v != "v1.0.0" {
+		t.Fatal("Expected the configured code version, but instead it is: ", v)
+	}
+
+	if v := items[1].custom["code_version"]; v != "v1.0.1" {
+		t.Fatal("Expected the updated code version, but instead it is: ", v)
+	}
+
+	if v, ok := items[2].custom["code_version"]; ok {
+		t.Fatal("Expected an empty code version to be omitted, but instead it is: ", v)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		Filter: func(entry *logrus.Entry) bool {
+			return entry.Data[logrus.ErrorKey] != context.Canceled
+		},
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithError(context.Canceled).Error("canceled")
+	log.WithError(errors.New("failed")).Error("failed")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	items := client.Items()
+	if len(items) != 1 || items[0].msg != "failed" {
+		t.Fatalf("Expected only the failed entry to be reported, got %v", items)
+	}
+
+	if stats := hook.Stats(); stats.Dropped != 1 {
+		t.Fatalf("Expected the filtered entry to be counted as dropped, got %+v", stats)
+	}
+}
+
+func TestFilterBeforeBuffer(t *testing.T) {
+	client := &fakeClient{delay: 100 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{
+		Buffer:     channel.NewBufferWithPolicy(1, buffer.DropNewest),
+		NumWorkers: 1,
+		Filter: func(entry *logrus.Entry) bool {
+			return entry.Message != "benign"
+		},
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 10; i++ {
+		log.Error("benign")
+	}
+
+	if n := hook.OverflowDropCount(); n != 0 {
+		t.Fatalf("Expected filtered entries not to take buffer space, got %d overflow drops", n)
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 0 {
+		t.Fatalf("Expected no items, got %d", n)
+	}
+}
+
+func TestAckDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rollrus-ack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := disk.NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{
+		Buffer:      b,
+		NumWorkers:  1,
+		ErrorLogger: ioutil.Discard,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("undelivered")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	// The next process delivers the entry and removes it.
+	b, err = disk.NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client = &fakeClient{}
+	hook = NewHookWithClient(client, RollrusConfig{Buffer: b, NumWorkers: 1})
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if items := client.Items(); len(items) != 1 || items[0].msg != "undelivered" {
+		t.Fatalf("Expected the undelivered entry to be replayed, got %v", items)
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("Expected the delivered entry to be removed, got %d files", len(files))
+	}
+}
+
+func TestNewHookWithWarnings(t *testing.T) {
+	hook := NewHookWithWarnings("", "testing")
+	defer hook.Close()
+
+	expected := []logrus.Level{logrus.WarnLevel, logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel}
+	if !reflect.DeepEqual(hook.Levels(), expected) {
+		t.Fatalf("Expected levels %v, got %v", expected, hook.Levels())
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Warn("not reported")
+
+	levels := []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel}
+	hook.SetLevels(levels)
+	levels[1] = logrus.InfoLevel
+
+	if !reflect.DeepEqual(hook.Levels(), []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel}) {
+		t.Fatal("Expected Levels() to return a copy of the new levels, got: ", hook.Levels())
+	}
+
+	log.ReplaceHooks(make(logrus.LevelHooks))
+	log.Hooks.Add(hook)
+	log.Warn("reported")
+
+	items := client.Items()
+	if len(items) != 1 || items[0].msg != "reported" {
+		t.Fatalf("Expected only the warning logged after SetLevels to be reported, got %+v", items)
+	}
+}
+
+func TestReportMessage(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 1})
+
+	fields := logrus.Fields{"job": "cleanup"}
+	if err := hook.ReportMessage(logrus.InfoLevel, "cleanup done", fields); err != nil {
+		t.Fatal("Expected ReportMessage to succeed, got: ", err)
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if items[0].severity != SeverityInfo || items[0].msg != "cleanup done" || items[0].custom["job"] != "cleanup" {
+		t.Fatalf("Unexpected item: %+v", items[0])
+	}
+	if len(fields) != 1 {
+		t.Fatal("Expected the fields not to be modified, got: ", fields)
+	}
+}
@@ -0,0 +1,41 @@
+package rollrus
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Router returns the Rollbar project, by its access token, and environment
+// to report entry to. An empty token reports it through the hook's client.
+type Router func(entry *log.Entry) (token, env string)
+
+// clientCache holds a client per token and environment returned by a Router.
+type clientCache struct {
+	mu        sync.Mutex
+	clients   map[[2]string]RollClient
+	newClient func(token, env string) RollClient
+}
+
+func newClientCache(config RollrusConfig) *clientCache {
+	return &clientCache{
+		clients: make(map[[2]string]RollClient),
+		newClient: func(token, env string) RollClient {
+			return newRollClient(token, env, config)
+		},
+	}
+}
+
+// get returns the client for token and env, creating it if needed.
+func (c *clientCache) get(token, env string) RollClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := [2]string{token, env}
+	client, ok := c.clients[key]
+	if !ok {
+		client = c.newClient(token, env)
+		c.clients[key] = client
+	}
+	return client
+}
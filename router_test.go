@@ -0,0 +1,52 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRouter(t *testing.T) {
+	defaultClient := &fakeClient{}
+	hook := NewHookWithClient(defaultClient, RollrusConfig{
+		SyncFire: true,
+		Router: func(entry *logrus.Entry) (string, string) {
+			if tenant, ok := entry.Data["tenant"].(string); ok {
+				return tenant + "-token", "production"
+			}
+			return "", ""
+		},
+	})
+	defer hook.Close()
+
+	tenants := make(map[string]*fakeClient)
+	hook.clients.newClient = func(token, env string) RollClient {
+		c := &fakeClient{}
+		tenants[token+"/"+env] = c
+		return c
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("tenant", "acme").Error("acme 1")
+	log.WithField("tenant", "acme").Error("acme 2")
+	log.WithField("tenant", "globex").Error("globex")
+	log.Error("default")
+
+	if len(tenants) != 2 {
+		t.Fatalf("Expected a client per tenant, got %d", len(tenants))
+	}
+
+	for key, n := range map[string]int{"acme-token/production": 2, "globex-token/production": 1} {
+		if c := tenants[key]; c == nil || len(c.Items()) != n {
+			t.Fatalf("Expected %d items for %s", n, key)
+		}
+	}
+
+	if items := defaultClient.Items(); len(items) != 1 || items[0].msg != "default" {
+		t.Fatalf("Expected unrouted entries on the default client, got %v", items)
+	}
+}
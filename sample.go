@@ -0,0 +1,52 @@
+package rollrus
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sampler randomly drops entries according to the configured sample rates.
+type sampler struct {
+	rate  float64
+	rates map[string]float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newSampler(rate float64, rates map[string]float64) *sampler {
+	if rate == 0 {
+		rate = 1
+	}
+	if rate >= 1 && len(rates) == 0 {
+		return nil
+	}
+
+	return &sampler{
+		rate:  rate,
+		rates: rates,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// keep reports whether entry was sampled. A nil sampler keeps every entry.
+func (s *sampler) keep(entry *log.Entry) bool {
+	if s == nil {
+		return true
+	}
+
+	rate, ok := s.rates[entry.Message]
+	if !ok {
+		rate = s.rate
+	}
+	if rate >= 1 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64() < rate
+}
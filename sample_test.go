@@ -0,0 +1,56 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSampleRate(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:    true,
+		SampleRate:  0.5,
+		SampleRates: map[string]float64{"noisy": 0, "important": 1},
+	})
+	hook.sampler.rand = rand.New(rand.NewSource(1))
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	expected := 0
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		log.Error("sampled")
+		if r.Float64() < 0.5 {
+			expected++
+		}
+
+		log.Error("noisy")
+		log.Error("important")
+	}
+
+	sampled, important := 0, 0
+	for _, item := range client.Items() {
+		switch item.msg {
+		case "sampled":
+			sampled++
+		case "important":
+			important++
+		default:
+			t.Fatal("Expected entry to be dropped: ", item.msg)
+		}
+	}
+
+	if sampled != expected {
+		t.Fatalf("Expected %d sampled entries, got %d", expected, sampled)
+	}
+
+	if important != 100 {
+		t.Fatalf("Expected all important entries to be sent, got %d", important)
+	}
+}
@@ -0,0 +1,136 @@
+package rollrus
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// scaler keeps track of the number of workers of a hook with a scaling
+// worker pool. Only dispatch grows the pool and only retireIdleWorkers
+// shrinks it, so the bounds hold without locking.
+type scaler struct {
+	min, max    int32
+	workers     int32
+	idleTimeout time.Duration
+}
+
+// grow reports whether a worker may be added, counting it if so.
+func (s *scaler) grow() bool {
+	if atomic.LoadInt32(&s.workers) >= s.max {
+		return false
+	}
+	atomic.AddInt32(&s.workers, 1)
+	return true
+}
+
+// retired uncounts a retired worker.
+func (s *scaler) retired() {
+	atomic.AddInt32(&s.workers, -1)
+}
+
+// atMin reports whether the pool has no more workers than its minimum.
+func (s *scaler) atMin() bool {
+	return atomic.LoadInt32(&s.workers) <= s.min
+}
+
+// WorkerCount returns the number of workers of the hook, which changes over
+// time with a scaling worker pool, see RollrusConfig.MaxWorkers. It is 0 with
+// SyncFire.
+func (r *Hook) WorkerCount() int {
+	if r.sync {
+		return 0
+	}
+	if r.scaler != nil {
+		return int(atomic.LoadInt32(&r.scaler.workers))
+	}
+	// Without scaling the pool holds the channels of exactly NumWorkers
+	// workers.
+	return cap(r.pool)
+}
+
+// IdleWorkerCount returns the number of workers waiting for an entry to send.
+func (r *Hook) IdleWorkerCount() int {
+	return len(r.pool)
+}
+
+// startWorker adds a worker to the pool.
+func (r *Hook) startWorker() {
+	r.wg.Add(1)
+	worker := newWorker(r.pool, r.closed, r.wg, r.counters, r.errorLog)
+	worker.Work()
+}
+
+// nextWorker returns the job channel of an idle worker, starting a new one
+// when none is idle and the pool can grow. It returns false when the hook is
+// aborted first.
+func (r *Hook) nextWorker() (chan job, bool) {
+	select {
+	case jobChannel := <-r.pool:
+		return jobChannel, true
+	default:
+	}
+
+	if r.scaler != nil && r.scaler.grow() {
+		r.startWorker()
+	}
+
+	select {
+	case jobChannel := <-r.pool:
+		return jobChannel, true
+	case <-r.abort:
+		return nil, false
+	}
+}
+
+// retireIdleWorkers retires the workers that stayed idle for an idleTimeout
+// until the hook is closed. Retiring a worker takes its job channel out of
+// the pool, so dispatch can't hand it an entry afterwards.
+func (r *Hook) retireIdleWorkers() {
+	ticker := time.NewTicker(r.scaler.idleTimeout)
+	defer ticker.Stop()
+
+	idle := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.closed:
+			return
+		}
+
+		// Workers idle now and at the previous tick.
+		retire := len(r.pool)
+		if idle < retire {
+			retire = idle
+		}
+
+		for ; retire > 0; retire-- {
+			if !r.retireWorker() {
+				break
+			}
+		}
+		idle = len(r.pool)
+	}
+}
+
+// retireWorker retires an idle worker, if any and the pool is above its
+// minimum, and reports whether it did.
+func (r *Hook) retireWorker() bool {
+	if r.scaler.atMin() {
+		return false
+	}
+
+	var jobChannel chan job
+	select {
+	case jobChannel = <-r.pool:
+	default:
+		return false
+	}
+
+	select {
+	case jobChannel <- job{retire: true}:
+		r.scaler.retired()
+		return true
+	case <-r.closed:
+		return false
+	}
+}
@@ -0,0 +1,86 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func waitForWorkers(t *testing.T, s *scaler, n int32) {
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&s.workers) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d workers, got %d", n, atomic.LoadInt32(&s.workers))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScalingWorkers(t *testing.T) {
+	client := &fakeClient{delay: 50 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{
+		MinWorkers:        1,
+		MaxWorkers:        4,
+		WorkerIdleTimeout: 20 * time.Millisecond,
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 6; i++ {
+		log.Error("burst")
+	}
+
+	waitForWorkers(t, hook.scaler, 4)
+	waitForWorkers(t, hook.scaler, 1)
+
+	log.Error("after the burst")
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	if n := len(client.Items()); n != 7 {
+		t.Fatalf("Expected 7 items, got %d", n)
+	}
+}
+
+func TestWorkerCount(t *testing.T) {
+	client := &fakeClient{delay: 50 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 2})
+	defer hook.Close()
+
+	if n := hook.WorkerCount(); n != 2 {
+		t.Fatalf("Expected 2 workers, got %d", n)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for hook.IdleWorkerCount() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 idle workers, got %d", hook.IdleWorkerCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("busy")
+
+	deadline = time.Now().Add(time.Second)
+	for hook.IdleWorkerCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 1 idle worker, got %d", hook.IdleWorkerCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := NewHookWithClient(client, RollrusConfig{SyncFire: true}).WorkerCount(); n != 0 {
+		t.Fatalf("Expected no workers with SyncFire, got %d", n)
+	}
+}
@@ -0,0 +1,158 @@
+package rollrus
+
+import (
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultScrubFields are the field names scrubbed when RollrusConfig doesn't
+// set ScrubFields. They cover the credentials carried by the request headers
+// reported with WithRequest, e.g. Cookie and X-Api-Key.
+var DefaultScrubFields = []string{
+	"password", "secret", "token", "authorization",
+	"cookie", "set-cookie", "api-key", "api_key",
+}
+
+const redacted = "[REDACTED]"
+
+// maxScrubDepth bounds how deep scrub looks into nested values, which may be
+// cyclic.
+const maxScrubDepth = 10
+
+type scrubber struct {
+	fields map[string]struct{}
+	fn     func(key string, value interface{}) (interface{}, bool)
+}
+
+func newScrubber(fields []string, fn func(key string, value interface{}) (interface{}, bool)) *scrubber {
+	if len(fields) == 0 && fn == nil {
+		return nil
+	}
+
+	s := &scrubber{fields: make(map[string]struct{}, len(fields)), fn: fn}
+	for _, f := range fields {
+		s.fields[strings.ToLower(f)] = struct{}{}
+	}
+	return s
+}
+
+// matches reports whether key, or its last dotted segment so that e.g.
+// request.headers.Authorization is covered by authorization, is scrubbed. A
+// name also covers the keys it ends with after a dash or an underscore, so
+// that api-key covers X-Api-Key and token covers access_token.
+func (s *scrubber) matches(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := s.fields[key]; ok {
+		return true
+	}
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	for f := range s.fields {
+		if key == f || strings.HasSuffix(key, "-"+f) || strings.HasSuffix(key, "_"+f) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrub returns a copy of fields with the sensitive values redacted or
+// dropped, including the sensitive keys of nested maps and structs, so that
+// they don't show up once flattened or formatted. A nil scrubber returns
+// fields as is.
+func (s *scrubber) scrub(fields log.Fields) log.Fields {
+	if s == nil {
+		return fields
+	}
+
+	scrubbed := make(log.Fields, len(fields))
+	for k, v := range fields {
+		if s.matches(k) {
+			scrubbed[k] = redacted
+			continue
+		}
+		if s.fn != nil {
+			var keep bool
+			if v, keep = s.fn(k, v); !keep {
+				continue
+			}
+		}
+		scrubbed[k], _ = s.scrubValue(v, maxScrubDepth)
+	}
+	return scrubbed
+}
+
+// scrubRich redacts the sensitive keys nested in the fields converted by
+// convertFieldsRich, whose keys are the JSON names of struct fields rather
+// than their Go names scrub looks at.
+func (s *scrubber) scrubRich(m map[string]interface{}) map[string]interface{} {
+	if s == nil {
+		return m
+	}
+
+	for k, v := range m {
+		m[k], _ = s.scrubValue(v, maxScrubDepth)
+	}
+	return m
+}
+
+// scrubValue returns v with the values of its sensitive map keys and struct
+// fields redacted, as a map[string]interface{} or []interface{}, and true
+// when any was. Otherwise it returns v itself and false.
+func (s *scrubber) scrubValue(v interface{}, depth int) (interface{}, bool) {
+	if depth <= 0 || !flattenable(v) {
+		return v, false
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+
+	changed := false
+	nested := func(key string, v interface{}) interface{} {
+		if s.matches(key) {
+			changed = true
+			return redacted
+		}
+		scrubbed, ok := s.scrubValue(v, depth-1)
+		changed = changed || ok
+		return scrubbed
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v, false
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[k.String()] = nested(k.String(), rv.MapIndex(k).Interface())
+		}
+		if changed {
+			return m, true
+		}
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				m[f.Name] = nested(f.Name, rv.Field(i).Interface())
+			}
+		}
+		if changed {
+			return m, true
+		}
+	case reflect.Slice, reflect.Array:
+		l := make([]interface{}, rv.Len())
+		for i := range l {
+			l[i] = nested("", rv.Index(i).Interface())
+		}
+		if changed {
+			return l, true
+		}
+	}
+	return v, false
+}
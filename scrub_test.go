@@ -0,0 +1,106 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestScrubDefaultFields(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"Password":                      "hunter2",
+		"request.headers.Authorization": "Bearer abc",
+		"user":                          "bob",
+	}).Error("scrubbed")
+
+	custom := client.Items()[0].custom
+	if v := custom["Password"]; v != "[REDACTED]" {
+		t.Fatal("Expected Password to be redacted, but instead it is: ", v)
+	}
+
+	if v := custom["request.headers.Authorization"]; v != "[REDACTED]" {
+		t.Fatal("Expected Authorization header to be redacted, but instead it is: ", v)
+	}
+
+	if v := custom["user"]; v != "bob" {
+		t.Fatal("Expected user to be reported, but instead it is: ", v)
+	}
+}
+
+func TestScrubFunc(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:    true,
+		ScrubFields: []string{},
+		ScrubFunc: func(key string, value interface{}) (interface{}, bool) {
+			if key == "ssn" {
+				return nil, false
+			}
+			if s, ok := value.(string); ok {
+				return strings.ToUpper(s), true
+			}
+			return value, true
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithFields(logrus.Fields{
+		"ssn":      "123-45-6789",
+		"password": "hunter2",
+	}).Error("scrubbed")
+
+	custom := client.Items()[0].custom
+	if v, ok := custom["ssn"]; ok {
+		t.Fatal("Expected ssn to be dropped, but instead it is: ", v)
+	}
+
+	if v := custom["password"]; v != "HUNTER2" {
+		t.Fatal("Expected password to go through ScrubFunc, but instead it is: ", v)
+	}
+}
+
+func TestScrubNestedFields(t *testing.T) {
+	type credentials struct {
+		User     string
+		Password string
+	}
+
+	for _, config := range []RollrusConfig{
+		{SyncFire: true},
+		{SyncFire: true, FlattenFields: true},
+	} {
+		client := &fakeClient{}
+		hook := NewHookWithClient(client, config)
+
+		log := logrus.New()
+		log.Out = ioutil.Discard
+		log.Hooks.Add(hook)
+
+		log.WithFields(logrus.Fields{
+			"creds":   map[string]interface{}{"user": "bob", "password": "hunter2"},
+			"account": &credentials{User: "bob", Password: "hunter2"},
+			"logins":  []credentials{{User: "bob", Password: "hunter2"}},
+		}).Error("scrubbed")
+		hook.Close()
+
+		for k, v := range client.Items()[0].custom {
+			if strings.Contains(v, "hunter2") {
+				t.Fatalf("Expected nested passwords to be redacted with %+v, but %s is: %s", config, k, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// Rollbar severities that logrus levels can be mapped to.
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+	SeverityDebug    = "debug"
+)
+
+var defaultLevelSeverities = map[log.Level]string{
+	log.PanicLevel: SeverityCritical,
+	log.FatalLevel: SeverityCritical,
+	log.ErrorLevel: SeverityError,
+	log.WarnLevel:  SeverityWarning,
+	log.InfoLevel:  SeverityInfo,
+	log.DebugLevel: SeverityDebug,
+	log.TraceLevel: SeverityDebug,
+}
+
+// severity returns the Rollbar severity entries logged at level are reported
+// with, falling back to the default mapping for levels that weren't
+// configured.
+func (r *Hook) severity(level log.Level) string {
+	if s, ok := r.severities[level]; ok {
+		return s
+	}
+	return defaultLevelSeverities[level]
+}
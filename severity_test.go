@@ -0,0 +1,70 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelSeverityMap(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:         true,
+		LogLevels:        []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel},
+		LevelSeverityMap: map[logrus.Level]string{logrus.WarnLevel: SeverityInfo},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Warn("mapped")
+	log.Error("default")
+
+	items := client.Items()
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+
+	if items[0].severity != SeverityInfo {
+		t.Fatal("Expected warning to be reported as info, got: ", items[0].severity)
+	}
+
+	if items[1].severity != SeverityError {
+		t.Fatal("Expected error to be reported as error, got: ", items[1].severity)
+	}
+}
+
+func TestDefaultLevelSeverities(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:  true,
+		LogLevels: logrus.AllLevels,
+	})
+	defer hook.Close()
+
+	expected := map[logrus.Level]string{
+		logrus.PanicLevel: SeverityCritical,
+		logrus.FatalLevel: SeverityCritical,
+		logrus.ErrorLevel: SeverityError,
+		logrus.WarnLevel:  SeverityWarning,
+		logrus.InfoLevel:  SeverityInfo,
+		logrus.DebugLevel: SeverityDebug,
+		logrus.TraceLevel: SeverityDebug,
+	}
+
+	log := logrus.New()
+	for level, severity := range expected {
+		client.items = nil
+		if err := hook.Fire(&logrus.Entry{Logger: log, Level: level, Message: "level"}); err != nil {
+			t.Fatal("Expected Fire to succeed, got: ", err)
+		}
+
+		items := client.Items()
+		if len(items) != 1 || items[0].severity != severity {
+			t.Fatalf("Expected %s entries to be reported as %s, got %v", level, severity, items)
+		}
+	}
+}
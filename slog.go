@@ -0,0 +1,133 @@
+//go:build go1.21
+
+package rollrus
+
+import (
+	"context"
+	"log/slog"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SlogHandler is a slog.Handler sending records to Rollbar through a Hook,
+// sharing its buffer, workers and field conversion.
+type SlogHandler struct {
+	hook   *Hook
+	attrs  log.Fields
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler reporting records to Rollbar. Record
+// levels are mapped to logrus levels, see SlogLevel, and only records whose
+// level is in config.LogLevels are reported, by default Error and above.
+func NewSlogHandler(token, env string, config RollrusConfig) *SlogHandler {
+	return NewSlogHandlerForHook(NewHookForLevels(token, env, config))
+}
+
+// NewSlogHandlerForHook returns a slog.Handler reporting records through hook,
+// which may also be used as a logrus hook.
+func NewSlogHandlerForHook(hook *Hook) *SlogHandler {
+	return &SlogHandler{hook: hook}
+}
+
+// SlogLevel maps a slog level to the closest logrus level.
+func SlogLevel(level slog.Level) log.Level {
+	switch {
+	case level >= slog.LevelError:
+		return log.ErrorLevel
+	case level >= slog.LevelWarn:
+		return log.WarnLevel
+	case level >= slog.LevelInfo:
+		return log.InfoLevel
+	default:
+		return log.DebugLevel
+	}
+}
+
+// Enabled reports whether records at level are sent to Rollbar.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	lvl := SlogLevel(level)
+	for _, l := range h.hook.Levels() {
+		if l == lvl {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle converts r to a logrus entry and fires the hook with it.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	data := make(log.Fields, len(h.attrs)+r.NumAttrs())
+	for k, v := range h.attrs {
+		data[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(data, h.prefix, a)
+		return true
+	})
+
+	return h.hook.Fire(&log.Entry{
+		Data:    data,
+		Time:    r.Time,
+		Level:   SlogLevel(r.Level),
+		Message: r.Message,
+		Context: ctx,
+	})
+}
+
+// WithAttrs returns a handler reporting attrs with every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := h.clone()
+	for _, a := range attrs {
+		addSlogAttr(h2.attrs, h2.prefix, a)
+	}
+	return h2
+}
+
+// WithGroup returns a handler prefixing the keys of the following attributes
+// with name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.prefix += name + "."
+	return h2
+}
+
+// Flush waits for the records handled so far to be sent, see Hook.Flush.
+func (h *SlogHandler) Flush() error {
+	return h.hook.Flush()
+}
+
+// Close closes the underlying hook, see Hook.Close.
+func (h *SlogHandler) Close() error {
+	return h.hook.Close()
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	attrs := make(log.Fields, len(h.attrs))
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	return &SlogHandler{hook: h.hook, attrs: attrs, prefix: h.prefix}
+}
+
+// addSlogAttr adds a to data, the keys of group attributes are prefixed with
+// the group name and a dot.
+func addSlogAttr(data log.Fields, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addSlogAttr(data, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	data[prefix+a.Key] = v.Any()
+}
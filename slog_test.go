@@ -0,0 +1,68 @@
+//go:build go1.21
+
+package rollrus
+
+import (
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	hook := NewTestHook()
+	defer hook.Close()
+
+	logger := slog.New(NewSlogHandlerForHook(hook.Hook)).
+		With("service", "api").
+		WithGroup("req")
+
+	logger.Info("ignored")
+	logger.Error("failed", "id", 42, slog.Group("user", "name", "bob"), "err", errors.New("boom"))
+
+	if !hook.WaitForEntries(1, time.Second) {
+		t.Fatal("Expected the record to be captured")
+	}
+
+	entries := hook.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 captured item, got %d", len(entries))
+	}
+
+	item := entries[0]
+	if item.Level != SeverityError || item.Message != "failed" {
+		t.Fatalf("Unexpected captured item: %+v", item)
+	}
+
+	expected := map[string]string{
+		"service":       "api",
+		"req.id":        "42",
+		"req.user.name": "bob",
+		"req.err":       "boom",
+	}
+	for k, v := range expected {
+		if item.Fields[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, item.Fields[k])
+		}
+	}
+}
+
+func TestSlogHandlerStack(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	slog.New(NewSlogHandlerForHook(hook)).Error("with stack")
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	frame, _ := runtime.CallersFrames(items[0].stack).Next()
+	if !strings.HasSuffix(frame.Function, ".TestSlogHandlerStack") {
+		t.Fatal("Expected the log call site on top of the stack, got: ", frame.Function)
+	}
+}
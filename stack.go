@@ -0,0 +1,137 @@
+package rollrus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const maxStackDepth = 64
+
+var pkgPath = reflect.TypeOf(Hook{}).PkgPath()
+
+type stackKey struct{}
+
+// withStack returns a copy of entry carrying the stack of the goroutine that
+// logged it, with the logrus and rollrus frames stripped so that the log call
+// site is on top. Entries already carrying a stack are returned as is.
+func withStack(entry *log.Entry) *log.Entry {
+	if stackFromContext(entry.Context) != nil {
+		return entry
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	pcs = pcs[:n]
+
+	frames := runtime.CallersFrames(pcs)
+	skip := 0
+	for {
+		frame, more := frames.Next()
+		if !internalFrame(frame) {
+			break
+		}
+		skip++
+		if !more {
+			break
+		}
+	}
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	e := *entry
+	e.Context = context.WithValue(ctx, stackKey{}, pcs[skip:])
+	return &e
+}
+
+// panicStack returns the stack of a panicking goroutine, to be called from a
+// deferred function. The frames of the deferred functions and of the runtime
+// panic machinery are stripped so that the frame that panicked is on top.
+func panicStack() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	pcs = pcs[:n]
+
+	frames := runtime.CallersFrames(pcs)
+	skip, panicking := 0, false
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return pcs[skip:]
+		}
+		skip++
+		if !more {
+			break
+		}
+	}
+
+	// Not called while panicking, report the whole stack.
+	return pcs
+}
+
+// internalFrame reports whether frame belongs to logrus, zerolog, zap, slog or
+// to this module, not counting its tests.
+func internalFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "github.com/sirupsen/logrus.") ||
+		strings.HasPrefix(frame.Function, "github.com/rs/zerolog.") ||
+		strings.HasPrefix(frame.Function, "go.uber.org/zap.") ||
+		strings.HasPrefix(frame.Function, "go.uber.org/zap/zapcore.") ||
+		strings.HasPrefix(frame.Function, "log/slog.") {
+		return true
+	}
+	return (strings.HasPrefix(frame.Function, pkgPath+".") || strings.HasPrefix(frame.Function, pkgPath+"/")) &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
+
+// stackFromContext returns the stack recorded by withStack.
+func stackFromContext(ctx context.Context) []uintptr {
+	if ctx == nil {
+		return nil
+	}
+	pcs, _ := ctx.Value(stackKey{}).([]uintptr)
+	return pcs
+}
+
+// SourceField is the field holding the file and line an entry was logged
+// from, when the logger reports it, see logrus.Logger.SetReportCaller.
+const SourceField = "source"
+
+// setSource reports the caller of entry, if known, under SourceField.
+func setSource(m map[string]string, entry *log.Entry) {
+	if entry.Caller == nil {
+		return
+	}
+	if _, exists := m[SourceField]; !exists {
+		m[SourceField] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+	}
+}
+
+// entryStack returns the stack to report for entry: the stack trace of its
+// errorKey field if it carries one, otherwise the stack recorded by withStack,
+// otherwise the frame of the caller reported by the logger, if any.
+func entryStack(entry *log.Entry, errorKey string, ctx context.Context) []uintptr {
+	if err, ok := entry.Data[errorKey].(error); ok {
+		if st := innermostStack(err); st != nil {
+			return stackPCs(st)
+		}
+	}
+
+	if pcs := stackFromContext(ctx); pcs != nil {
+		return pcs
+	}
+
+	if entry.Caller != nil {
+		// Frames are looked up from return addresses, one past the call.
+		return []uintptr{entry.Caller.PC + 1}
+	}
+	return nil
+}
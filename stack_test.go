@@ -0,0 +1,152 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func topFunction(stack []uintptr) string {
+	frame, _ := runtime.CallersFrames(stack).Next()
+	return frame.Function
+}
+
+func TestCaptureStack(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("foo", "bar").Error("with stack")
+
+	items := client.Items()
+	if len(items) != 1 || len(items[0].stack) == 0 {
+		t.Fatal("Expected the entry to be reported with a stack trace")
+	}
+
+	if fn := topFunction(items[0].stack); !strings.HasSuffix(fn, ".TestCaptureStack") {
+		t.Fatal("Expected the log call site on top of the stack, got: ", fn)
+	}
+}
+
+func TestErrorFieldStack(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:            true,
+		DisableStackCapture: true,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	err := newStackError()
+	log.WithError(err).Error("with error stack")
+	log.Error("without stack")
+
+	items := client.Items()
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+
+	if fn := topFunction(items[0].stack); !strings.HasSuffix(fn, ".newStackError") {
+		t.Fatal("Expected the stack of the error, got: ", fn)
+	}
+
+	if len(items[1].stack) != 0 {
+		t.Fatal("Expected no stack trace with DisableStackCapture")
+	}
+}
+
+func TestStackTraceField(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:        true,
+		StackTraceField: "cause",
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("cause", newStackError()).Error("with cause stack")
+	log.WithError(newStackError()).Error("with error stack")
+
+	items := client.Items()
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+
+	if fn := topFunction(items[0].stack); !strings.HasSuffix(fn, ".newStackError") {
+		t.Fatal("Expected the stack of the cause field, got: ", fn)
+	}
+
+	if fn := topFunction(items[1].stack); !strings.HasSuffix(fn, ".TestStackTraceField") {
+		t.Fatal("Expected the stack of the log call site, got: ", fn)
+	}
+}
+
+func newStackError() error {
+	return pkgerrors.New("boom")
+}
+
+//go:noinline
+func panicking() {
+	panic("boom")
+}
+
+func TestReportPanicStack(t *testing.T) {
+	client := &fakeClient{}
+	hook := &Hook{Client: client}
+
+	func() {
+		defer func() {
+			recover()
+		}()
+		defer hook.ReportPanic()
+		panicking()
+	}()
+
+	items := client.Items()
+	if len(items) != 1 || len(items[0].stack) == 0 {
+		t.Fatal("Expected the panic to be reported with a stack trace")
+	}
+
+	if fn := topFunction(items[0].stack); !strings.HasSuffix(fn, ".panicking") {
+		t.Fatal("Expected the panicking function on top of the stack, got: ", fn)
+	}
+}
+
+func TestReportCaller(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true, DisableStackCapture: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.SetReportCaller(true)
+	log.Hooks.Add(hook)
+
+	log.Error("with caller")
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	if len(items[0].stack) != 1 || !strings.HasSuffix(topFunction(items[0].stack), ".TestReportCaller") {
+		t.Fatalf("Expected a single frame for the caller, got %d frames", len(items[0].stack))
+	}
+	if source := items[0].custom[SourceField]; !strings.Contains(source, "stack_test.go:") {
+		t.Fatal("Expected the caller to be reported as the source, got: ", source)
+	}
+}
@@ -0,0 +1,160 @@
+package rollrus
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/benjamindow/rollrus/buffer"
+)
+
+// Stats is a snapshot of the counters of a Hook.
+type Stats struct {
+	// Buffered is the number of entries waiting to be sent.
+	Buffered int
+	// Dropped is the number of entries that were never sent.
+	Dropped uint64
+	// Sent is the number of entries successfully sent to Rollbar.
+	Sent uint64
+	// Failed is the number of entries that could not be sent to Rollbar.
+	Failed uint64
+	// FailedAfterRetries is the number of Failed entries that were retried,
+	// see RollrusConfig.MaxRetries.
+	FailedAfterRetries uint64
+}
+
+// counters are kept behind a pointer so that they are 64-bit aligned for
+// atomic access on 32-bit platforms.
+type counters struct {
+	pending int64
+	dropped uint64
+	success uint64
+	failed  uint64
+	retried uint64
+
+	metrics Metrics
+	// overflow returns the number of entries dropped by the buffer, which
+	// are still counted as pending.
+	overflow func() int64
+}
+
+func newCounters(m Metrics, overflow func() int64) *counters {
+	return &counters{metrics: m, overflow: overflow}
+}
+
+// m returns the Metrics of the counters, which are NopMetrics when none was
+// set.
+func (c *counters) m() Metrics {
+	if c.metrics == nil {
+		return NopMetrics{}
+	}
+	return c.metrics
+}
+
+// fire counts an entry passed to Fire, for the Metrics that implement
+// FireMetrics.
+func (c *counters) fire() {
+	if fm, ok := c.metrics.(FireMetrics); ok {
+		fm.IncFired()
+	}
+}
+
+func (c *counters) sent(err error) {
+	if err != nil {
+		atomic.AddUint64(&c.failed, 1)
+		if de, ok := err.(*deliveryError); ok && de.attempts > 1 {
+			atomic.AddUint64(&c.retried, 1)
+		}
+		c.m().IncFailed()
+	} else {
+		atomic.AddUint64(&c.success, 1)
+		c.m().IncSent()
+	}
+}
+
+func (c *counters) reject() {
+	atomic.AddUint64(&c.dropped, 1)
+	c.m().IncDropped()
+}
+
+func (c *counters) drop() {
+	c.setDepth(atomic.AddInt64(&c.pending, -1))
+	atomic.AddUint64(&c.dropped, 1)
+	c.m().IncDropped()
+}
+
+// push counts an entry pushed to the buffer.
+func (c *counters) push() {
+	c.setDepth(atomic.AddInt64(&c.pending, 1))
+}
+
+// done counts a buffered entry that was sent or failed.
+func (c *counters) done(err error, start time.Time) {
+	c.m().ObserveLatency(time.Since(start))
+	c.sent(err)
+	c.setDepth(atomic.AddInt64(&c.pending, -1))
+}
+
+// setDepth reports the buffer depth given the number of pending entries.
+func (c *counters) setDepth(pending int64) {
+	n := pending
+	if c.overflow != nil {
+		n -= c.overflow()
+	}
+	if n < 0 {
+		n = 0
+	}
+	c.m().SetBufferDepth(int(n))
+}
+
+// Stats returns a snapshot of the hook's counters. It is cheap enough to be
+// polled periodically and reported to a metrics system.
+func (r *Hook) Stats() Stats {
+	overflow := r.OverflowDropCount()
+	return Stats{
+		Buffered: int(atomic.LoadInt64(&r.counters.pending) - overflow),
+		Dropped:  atomic.LoadUint64(&r.counters.dropped) + uint64(overflow),
+		Sent:     atomic.LoadUint64(&r.counters.success),
+		Failed:   atomic.LoadUint64(&r.counters.failed),
+
+		FailedAfterRetries: atomic.LoadUint64(&r.counters.retried),
+	}
+}
+
+// QueueDepth returns the number of entries waiting in the buffer or being
+// sent by a worker.
+func (r *Hook) QueueDepth() int {
+	if n := r.Stats().Buffered; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// Idle reports whether every entry fired so far has been sent to Rollbar or
+// dropped, i.e. whether the buffer is empty and no worker is sending an
+// entry. Flush waits for the hook to be idle.
+func (r *Hook) Idle() bool {
+	return r.Stats().Buffered <= 0
+}
+
+// DroppedCount returns the number of entries that were never sent to Rollbar,
+// for instance because they were rejected by the configured Limiter.
+func (r *Hook) DroppedCount() int64 {
+	return int64(atomic.LoadUint64(&r.counters.dropped)) + r.OverflowDropCount()
+}
+
+// DroppedEntries returns the number of entries that were never sent to
+// Rollbar, whether they were rejected before reaching the buffer or dropped
+// because it was full. It is the same as DroppedCount.
+func (r *Hook) DroppedEntries() int64 {
+	return r.DroppedCount()
+}
+
+// OverflowDropCount returns the number of entries dropped because the buffer
+// was full, see RollrusConfig.BufferOverflowPolicy. It is always 0 for buffers
+// that don't implement buffer.Dropper.
+func (r *Hook) OverflowDropCount() int64 {
+	if d, ok := r.entries.(buffer.Dropper); ok {
+		return d.Dropped()
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+package rollrus
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/benjamindow/rollrus/buffer"
+	"github.com/benjamindow/rollrus/buffer/channel"
+	"github.com/benjamindow/rollrus/buffer/disk"
+	"github.com/sirupsen/logrus"
+)
+
+func TestStats(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 2})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 5; i++ {
+		log.Error("counted")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	expected := Stats{Sent: 5}
+	if stats := hook.Stats(); stats != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestStatsFailed(t *testing.T) {
+	client := &fakeClient{err: errors.New("unreachable")}
+	hook := NewHookWithClient(client, RollrusConfig{SyncFire: true})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("not delivered")
+
+	expected := Stats{Failed: 1}
+	if stats := hook.Stats(); stats != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestOverflowDropCount(t *testing.T) {
+	// No dispatcher is running, so entries pile up in the buffer.
+	hook := &Hook{
+		entries:  channel.NewBufferWithPolicy(1, buffer.DropNewest),
+		counters: new(counters),
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 3; i++ {
+		log.Error("overflow")
+	}
+
+	if n := hook.OverflowDropCount(); n != 2 {
+		t.Fatalf("Expected 2 overflow drops, got %d", n)
+	}
+
+	expected := Stats{Buffered: 1, Dropped: 2}
+	if stats := hook.Stats(); stats != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestDroppedEntries(t *testing.T) {
+	client := &fakeClient{delay: 20 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{
+		NumWorkers: 1,
+		Buffer:     channel.NewBufferWithPolicy(1, buffer.DropNewest),
+	})
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 10; i++ {
+		log.Error("overflow")
+	}
+
+	if n := hook.DroppedEntries(); n == 0 {
+		t.Fatal("Expected the entries overflowing the buffer to be dropped")
+	}
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+	if n, sent := hook.DroppedEntries(), len(client.Items()); n+int64(sent) != 10 {
+		t.Fatalf("Expected every entry to be sent or dropped, got %d sent and %d dropped", sent, n)
+	}
+}
+
+func TestStatsReplayedEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rollrus-stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := disk.NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Push(logrus.NewEntry(logrus.New()))
+	b.Close()
+
+	b, err = disk.NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{Buffer: b, NumWorkers: 1})
+
+	if err := hook.Close(); err != nil {
+		t.Fatal("Expected Close to succeed, got: ", err)
+	}
+
+	expected := Stats{Sent: 1}
+	if stats := hook.Stats(); stats != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestIdle(t *testing.T) {
+	client := &fakeClient{delay: 20 * time.Millisecond}
+	hook := NewHookWithClient(client, RollrusConfig{NumWorkers: 1})
+	defer hook.Close()
+
+	if !hook.Idle() {
+		t.Fatal("Expected a new hook to be idle")
+	}
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.Error("in flight")
+
+	if hook.Idle() || hook.QueueDepth() != 1 {
+		t.Fatal("Expected the hook not to be idle while sending an entry")
+	}
+
+	hook.Flush()
+
+	if !hook.Idle() || len(client.Items()) != 1 {
+		t.Fatal("Expected the hook to be idle once the entry was sent")
+	}
+}
@@ -0,0 +1,117 @@
+package rollrus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CapturedItem is an item a TestHook would have sent to Rollbar.
+type CapturedItem struct {
+	// Level is the Rollbar severity of the item, e.g. SeverityError.
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+// TestHook is a Hook that records the items it would have sent to Rollbar
+// instead of sending them, for use in unit tests. Entries go through the same
+// buffer, workers and field conversion as with a regular Hook.
+type TestHook struct {
+	*Hook
+	client *captureClient
+}
+
+// NewTestHook returns a TestHook handling the default reporting levels.
+func NewTestHook() *TestHook {
+	return NewTestHookForLevels(RollrusConfig{})
+}
+
+// NewTestHookForLevels returns a TestHook configured like
+// NewHookForLevels would.
+func NewTestHookForLevels(config RollrusConfig) *TestHook {
+	client := &captureClient{}
+	return &TestHook{Hook: NewHookWithClient(client, config), client: client}
+}
+
+// Entries returns the items captured so far.
+func (t *TestHook) Entries() []CapturedItem {
+	t.client.mu.Lock()
+	defer t.client.mu.Unlock()
+	return append([]CapturedItem(nil), t.client.items...)
+}
+
+// Reset discards the items captured so far.
+func (t *TestHook) Reset() {
+	t.client.mu.Lock()
+	defer t.client.mu.Unlock()
+	t.client.items = nil
+}
+
+// WaitForEntries waits up to timeout for at least n items to be captured and
+// reports whether they were.
+func (t *TestHook) WaitForEntries(n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if len(t.Entries()) >= n {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(flushInterval)
+	}
+}
+
+// WaitForEmpty waits up to timeout for every entry fired so far to be
+// captured or dropped and reports whether they were, see Hook.Idle.
+func (t *TestHook) WaitForEmpty(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return t.FlushWithContext(ctx) == nil
+}
+
+// captureClient is a roll.Client recording items instead of sending them.
+type captureClient struct {
+	mu    sync.Mutex
+	items []CapturedItem
+}
+
+func (c *captureClient) capture(level, msg string, custom map[string]string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, CapturedItem{Level: level, Message: msg, Fields: custom})
+	return "", nil
+}
+
+func (c *captureClient) Critical(err error, custom map[string]string) (string, error) {
+	return c.capture(SeverityCritical, err.Error(), custom)
+}
+
+func (c *captureClient) CriticalStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return c.capture(SeverityCritical, err.Error(), custom)
+}
+
+func (c *captureClient) Error(err error, custom map[string]string) (string, error) {
+	return c.capture(SeverityError, err.Error(), custom)
+}
+
+func (c *captureClient) ErrorStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return c.capture(SeverityError, err.Error(), custom)
+}
+
+func (c *captureClient) Warning(err error, custom map[string]string) (string, error) {
+	return c.capture(SeverityWarning, err.Error(), custom)
+}
+
+func (c *captureClient) WarningStack(err error, ptrs []uintptr, custom map[string]string) (string, error) {
+	return c.capture(SeverityWarning, err.Error(), custom)
+}
+
+func (c *captureClient) Info(msg string, custom map[string]string) (string, error) {
+	return c.capture(SeverityInfo, msg, custom)
+}
+
+func (c *captureClient) Debug(msg string, custom map[string]string) (string, error) {
+	return c.capture(SeverityDebug, msg, custom)
+}
@@ -0,0 +1,66 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTestHook(t *testing.T) {
+	hook := NewTestHook()
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField("user", "bob").Error("captured")
+	log.Info("ignored")
+
+	if !hook.WaitForEntries(1, time.Second) {
+		t.Fatal("Expected the entry to be captured")
+	}
+
+	entries := hook.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 captured item, got %d", len(entries))
+	}
+
+	item := entries[0]
+	if item.Level != SeverityError || item.Message != "captured" || item.Fields["user"] != "bob" {
+		t.Fatalf("Unexpected captured item: %+v", item)
+	}
+
+	hook.Reset()
+
+	if n := len(hook.Entries()); n != 0 {
+		t.Fatalf("Expected no captured items after Reset, got %d", n)
+	}
+
+	if hook.WaitForEntries(1, 10*time.Millisecond) {
+		t.Fatal("Expected WaitForEntries to time out")
+	}
+}
+
+func TestWaitForEmpty(t *testing.T) {
+	hook := NewTestHook()
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	for i := 0; i < 10; i++ {
+		log.Error("captured")
+	}
+
+	if !hook.WaitForEmpty(time.Second) {
+		t.Fatal("Expected the buffer to be drained")
+	}
+
+	if n := len(hook.Entries()); n != 10 {
+		t.Fatalf("Expected 10 captured items once empty, got %d", n)
+	}
+}
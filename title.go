@@ -0,0 +1,34 @@
+package rollrus
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// TitleField is the entry field overriding the title of the Rollbar item,
+// which is otherwise the message of the entry.
+const TitleField = "rollbar_title"
+
+// TitleFunc returns the title of the Rollbar item reported for entry. An
+// empty title keeps the message of the entry.
+type TitleFunc func(entry *log.Entry) string
+
+// setTitle returns the title of the item reported with the fields m. The
+// TitleField of m, or else the title returned by fn, replaces the message of
+// entry, which is then reported under the message custom field.
+func setTitle(m map[string]string, fn TitleFunc, entry *log.Entry) string {
+	title, ok := m[TitleField]
+	if ok {
+		delete(m, TitleField)
+	}
+	if title == "" && fn != nil {
+		title = fn(entry)
+	}
+
+	if title == "" || title == entry.Message {
+		return entry.Message
+	}
+	if _, exists := m["message"]; !exists {
+		m["message"] = entry.Message
+	}
+	return title
+}
@@ -0,0 +1,82 @@
+package rollrus
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestTitle(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire: true,
+		TitleFunc: func(entry *logrus.Entry) string {
+			if strings.HasPrefix(entry.Message, "order ") {
+				return "order failed"
+			}
+			return ""
+		},
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	log.WithField(TitleField, "payment failed").Error("payment 42 failed")
+	log.Error("order 43 failed")
+	log.Error("untitled")
+
+	items := client.Items()
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+
+	expected := []struct{ title, message string }{
+		{"payment failed", "payment 42 failed"},
+		{"order failed", "order 43 failed"},
+		{"untitled", ""},
+	}
+	for i, e := range expected {
+		if items[i].msg != e.title {
+			t.Errorf("Expected item %d to be titled %q, got %q", i, e.title, items[i].msg)
+		}
+		if got := items[i].custom["message"]; got != e.message {
+			t.Errorf("Expected item %d message to be %q, got %q", i, e.message, got)
+		}
+		if _, ok := items[i].custom[TitleField]; ok {
+			t.Errorf("Expected item %d not to report %s", i, TitleField)
+		}
+	}
+}
+
+func TestIncludeMessageAsField(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:              true,
+		IncludeMessageAsField: true,
+	})
+	defer hook.Close()
+
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	log.Hooks.Add(hook)
+
+	msg := "a long line " + strings.Repeat("with detail ", 100)
+	log.Error(msg)
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	if items[0].msg != msg {
+		t.Errorf("Expected the message to be the title, got %q", items[0].msg)
+	}
+
+	if got := items[0].custom["message"]; got != msg {
+		t.Errorf("Expected the message field to hold the message, got %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+package rollrus
+
+import (
+	"context"
+	"strings"
+)
+
+// TraceFields returns the tracing metadata carried by ctx: the values of the
+// well known context keys and, unless those are set, the B3 or W3C trace
+// context headers of a request attached with WithRequest. Panics are reported
+// with it, see ReportPanicWithContext.
+func TraceFields(ctx context.Context) map[string]string {
+	m := make(map[string]string)
+	mergeContext(ctx, m)
+
+	r := requestFromContext(ctx)
+	if r == nil {
+		return m
+	}
+
+	var traceID, spanID string
+	if traceID = r.Header.Get("X-B3-TraceId"); traceID != "" {
+		spanID = r.Header.Get("X-B3-SpanId")
+	} else if traceparent := r.Header.Get("Traceparent"); traceparent != "" {
+		m["traceparent"] = traceparent
+		// version-trace_id-parent_id-flags
+		if parts := strings.Split(traceparent, "-"); len(parts) == 4 {
+			traceID, spanID = parts[1], parts[2]
+		}
+	}
+
+	if _, exists := m["trace_id"]; !exists && traceID != "" {
+		m["trace_id"] = traceID
+	}
+	if spanID != "" {
+		m["span_id"] = spanID
+	}
+
+	return m
+}
@@ -0,0 +1,140 @@
+package rollrus
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestReportPanicWithContext(t *testing.T) {
+	client := &fakeClient{}
+	hook := &Hook{Client: client}
+
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := WithRequest(context.Background(), req)
+	ctx = context.WithValue(ctx, RequestIDKey, "abc123")
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatal("Expected the panic to be re-raised, got: ", p)
+			}
+		}()
+		defer hook.ReportPanicWithContext(ctx)
+		panic("boom")
+	}()
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	if items[0].severity != SeverityCritical {
+		t.Fatal("Expected panic to be reported as critical, got: ", items[0].severity)
+	}
+
+	expected := map[string]string{
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"request_id": "abc123",
+	}
+	for k, v := range expected {
+		if items[0].custom[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, items[0].custom[k])
+		}
+	}
+}
+
+func TestReportPanicWithFields(t *testing.T) {
+	client := &fakeClient{}
+	hook := &Hook{Client: client}
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatal("Expected the panic to be re-raised, got: ", p)
+			}
+		}()
+		defer hook.ReportPanicWithFields(logrus.Fields{"endpoint": "/users", "user_id": 42})
+		panic("boom")
+	}()
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	expected := map[string]string{"endpoint": "/users", "user_id": "42"}
+	for k, v := range expected {
+		if items[0].custom[k] != v {
+			t.Fatalf("Expected %s to equal %q, but instead it is: %q", k, v, items[0].custom[k])
+		}
+	}
+}
+
+func TestTraceFieldsB3(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("X-B3-TraceId", "trace")
+	req.Header.Set("X-B3-SpanId", "span")
+
+	m := TraceFields(WithRequest(context.Background(), req))
+	if m["trace_id"] != "trace" || m["span_id"] != "span" {
+		t.Fatal("Expected B3 headers to be reported, got: ", m)
+	}
+}
+
+func TestPanicFormatter(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire: true,
+		PanicFormatter: func(p interface{}) error {
+			return fmt.Errorf("crashed: %v", p)
+		},
+	})
+	defer hook.Close()
+
+	func() {
+		defer func() { recover() }()
+		defer hook.ReportPanic()
+		panic("boom")
+	}()
+
+	items := client.Items()
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+	if items[0].msg != "crashed: boom" {
+		t.Fatalf("Expected the formatted panic to be reported, got: %q", items[0].msg)
+	}
+}
+
+func TestReportPanicFieldsScrubbed(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHookWithClient(client, RollrusConfig{
+		SyncFire:       true,
+		FieldBlacklist: []string{"internal_*"},
+		DefaultFields:  map[string]string{"service": "api"},
+	})
+	defer hook.Close()
+
+	func() {
+		defer func() { recover() }()
+		defer hook.ReportPanicWithFields(logrus.Fields{"password": "hunter2", "internal_id": 7})
+		panic("boom")
+	}()
+
+	custom := client.Items()[0].custom
+	if v := custom["password"]; v != "[REDACTED]" {
+		t.Fatal("Expected password to be redacted, but instead it is: ", v)
+	}
+	if v, ok := custom["internal_id"]; ok {
+		t.Fatal("Expected internal_id to be filtered out, but instead it is: ", v)
+	}
+	if v := custom["service"]; v != "api" {
+		t.Fatal("Expected the default fields to be reported, but instead service is: ", v)
+	}
+}
@@ -1,51 +1,360 @@
 package rollrus
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
 	"sync"
 	"time"
 
+	"github.com/benjamindow/rollrus/buffer"
 	log "github.com/sirupsen/logrus"
-	"github.com/stvp/roll"
 )
 
+// ContextKey is the type of the well known context keys whose values are
+// reported to Rollbar along with the fields of an entry logged with a context.
+type ContextKey string
+
+const (
+	// RequestIDKey is the context key holding the ID of the current request.
+	RequestIDKey ContextKey = "request_id"
+	// TraceIDKey is the context key holding the ID of the current trace.
+	TraceIDKey ContextKey = "trace_id"
+)
+
+var contextKeys = []ContextKey{RequestIDKey, TraceIDKey}
+
 type job struct {
-	client roll.Client
-	entry  *log.Entry
+	client   RollClient
+	entry    *log.Entry
+	ctx      context.Context
+	severity string
+	scrubber *scrubber
+	// fieldList can be nil.
+	fieldList *fieldList
+	// beforeSend may change or skip the report, it can be nil.
+	beforeSend BeforeSendFunc
+	person     PersonFieldNames
+	personFunc PersonFromEntry
+	// fingerprint can be nil.
+	fingerprint FingerprintFunc
+	// title can be nil.
+	title TitleFunc
+	// hostname, or else the hostnameField of the entry, overrides the
+	// hostname of the item when not empty.
+	hostname      string
+	hostnameField string
+	// withMessage is whether the message is always reported as a field.
+	withMessage bool
+	breaker     *breaker
+	// codeVersion is omitted when empty.
+	codeVersion string
+	// defaults are added to the fields the entry doesn't set.
+	defaults map[string]string
+	retry    retryPolicy
+	// debug logs the retries, it can be nil.
+	debug *debugLogger
+	// stop interrupts the retries when closed.
+	stop <-chan struct{}
+	// onDrop is called with the entry when it could not be sent, it can be
+	// nil.
+	onDrop OnDropFunc
+	// stacks is whether the stack recorded by withStack is reported.
+	stacks bool
+	// errorKey is the field whose error stack trace is reported.
+	errorKey string
+	// otel is whether the OpenTelemetry trace context is reported.
+	otel bool
+	// causes is whether the trace chain of error fields is reported.
+	causes bool
+	// acker is the buffer to acknowledge the entry to once it's settled, it
+	// can be nil.
+	acker buffer.Acker
+	ackID string
+	// retire makes the worker receiving the job exit.
+	retire bool
+	// flattenDepth is how deep nested fields are flattened, 0 disables it.
+	flattenDepth int
+	// richFields is whether fields are converted with convertFieldsRich.
+	richFields bool
+}
+
+// newJob returns the job sending entry to Rollbar as configured on the hook.
+func (r *Hook) newJob(entry *log.Entry) job {
+	j := job{
+		client:        r.Client,
+		entry:         entry,
+		scrubber:      r.scrubber,
+		fieldList:     r.fieldList,
+		beforeSend:    r.beforeSend,
+		person:        r.person,
+		personFunc:    r.personFunc,
+		fingerprint:   r.fingerprint,
+		title:         r.title,
+		hostname:      r.hostname,
+		hostnameField: r.hostnameField,
+		withMessage:   r.withMessage,
+		breaker:       r.breaker,
+		defaults:      r.defaults,
+		retry:         r.retry,
+		debug:         r.debugLog,
+		stop:          r.closing,
+		stacks:        r.stacks,
+		errorKey:      r.errorKey,
+		causes:        r.causes,
+		otel:          r.otel,
+		onDrop:        r.onDrop,
+		flattenDepth:  r.flattenDepth,
+		richFields:    r.richFields,
+	}
+	if entry != nil {
+		j.ctx = entry.Context
+		j.severity = r.severity(entry.Level)
+		if r.router != nil {
+			if token, env := r.router(entry); token != "" {
+				j.client = r.clients.get(token, env)
+			}
+		}
+	}
+	if r.codeVersion != nil {
+		j.codeVersion, _ = r.codeVersion.Load().(string)
+	}
+	return j
+}
+
+var (
+	// errSkipped is returned by sendToRollbar when BeforeSend drops the
+	// report.
+	errSkipped = errors.New("rollrus: report skipped")
+)
+
+// settled reports whether err is the final outcome of sending an entry, so
+// that it doesn't need to be replayed.
+func settled(err error) bool {
+	switch err := err.(type) {
+	case *deliveryError:
+		return permanent(err.err)
+	case *panicError:
+		return true
+	}
+	return err == nil || err == errSkipped
+}
+
+// skipped reports whether err means the entry was dropped rather than sent.
+func skipped(err error) bool {
+	return err == errSkipped || err == ErrCircuitOpen
+}
+
+// panicError is returned by deliver when sending an entry panicked, e.g. in
+// the client or in BeforeSend.
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("rollrus: panic while sending entry: %v", e.value)
+}
+
+// deliver sends the entry like sendToRollbar, but recovers from panics so
+// that a bad entry or callback doesn't take down the worker.
+func (j job) deliver() (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &panicError{value: p}
+		}
+	}()
+	return j.sendToRollbar()
 }
 
-func (j job) sendToRollbar() {
+func (j job) sendToRollbar() error {
 	entry := j.entry
 
 	if entry == nil {
-		return
+		return nil
 	}
 
-	e := fmt.Errorf(entry.Message)
-	m := convertFields(entry.Data)
+	m, rich := j.customFields(entry.Data)
 	if _, exists := m["time"]; !exists {
 		m["time"] = entry.Time.Format(time.RFC3339)
 	}
+	j.setDefaults(m)
+	mergeContext(j.ctx, m)
+	if j.otel {
+		setSpanContext(m, j.ctx)
+	}
+	j.person.setPerson(m)
+	setSource(m, entry)
+	if j.personFunc != nil {
+		j.personFunc(entry).set(m)
+	}
+	setFingerprint(m, j.fingerprint, entry)
+	title := setTitle(m, j.title, entry)
+	setHostname(m, j.hostname, j.hostnameField)
+	if _, exists := m["message"]; !exists && j.withMessage {
+		m["message"] = entry.Message
+	}
+	if _, exists := m["code_version"]; !exists && j.codeVersion != "" {
+		m["code_version"] = j.codeVersion
+	}
+
+	if j.beforeSend != nil {
+		var ok bool
+		if m, ok = j.beforeSend(entry, m); !ok {
+			return errSkipped
+		}
+	}
+
+	e := fmt.Errorf(title)
+	ctx := j.ctx
+	if !j.stacks {
+		ctx = nil
+	}
+	stack := entryStack(entry, j.errorKey, ctx)
+	chain := j.traceChain(entry.Data)
+	for attempt := 1; ; attempt++ {
+		if !j.breaker.allow() {
+			return ErrCircuitOpen
+		}
+
+		err := j.attempt(e, title, m, rich, stack, chain)
+		if err == nil {
+			return nil
+		}
+
+		if permanent(err) || attempt > j.retry.max {
+			return &deliveryError{err: err, attempts: attempt}
+		}
+		j.debug.log("retrying delivery", log.Fields{log.ErrorKey: err, "attempt": attempt})
+		if !j.retry.wait(attempt, j.stop) {
+			return &deliveryError{err: err, attempts: attempt}
+		}
+	}
+}
+
+// customFields returns the custom data reporting fields: the fields kept by
+// the fieldList, scrubbed and converted as configured, and the fields
+// converted to rich, if any, see report.
+func (j job) customFields(data log.Fields) (map[string]string, map[string]interface{}) {
+	fields := j.scrubber.scrub(j.fieldList.filter(data))
+	var m map[string]string
+	var rich map[string]interface{}
+	if j.richFields {
+		rich = j.scrubber.scrubRich(convertFieldsRich(fields))
+		m = encodeRichFields(rich)
+	} else {
+		m = flattenFields(fields, j.flattenDepth)
+	}
+	if j.causes {
+		for k, v := range fields {
+			if _, ok := j.client.(*httpClient); ok && k == j.errorKey {
+				// Reported as the trace chain of the item, see traceChain.
+				continue
+			}
+			if err, ok := v.(error); ok {
+				setTraceChain(m, k, err)
+			}
+		}
+	}
+	return m, rich
+}
+
+// traceChain returns the errors reported as the trace chain of the item when
+// the client builds it: the ones wrapped by the error field of data that
+// recorded a stack trace, see RollrusConfig.WrapCauses.
+func (j job) traceChain(data log.Fields) []error {
+	if _, ok := j.client.(*httpClient); !ok || !j.causes {
+		return nil
+	}
+	err, _ := j.fieldList.filter(data)[j.errorKey].(error)
+	return stackedErrors(err)
+}
+
+// setDefaults adds the default fields m doesn't set.
+func (j job) setDefaults(m map[string]string) {
+	for k, v := range j.defaults {
+		if _, exists := m[k]; !exists {
+			m[k] = v
+		}
+	}
+}
+
+// attempt reports the entry once and records the outcome with the breaker, as
+// a failure when the client panics so that a probe doesn't leave the circuit
+// half-open.
+func (j job) attempt(e error, msg string, m map[string]string, rich map[string]interface{}, stack []uintptr, chain []error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			j.breaker.record(&panicError{value: p})
+			panic(p)
+		}
+	}()
+
+	err := j.report(e, msg, m, rich, stack, chain)
+	j.breaker.record(err)
+	return err
+}
+
+// report calls the client method matching the severity of the job, with e
+// or msg as the title of the item. Clients sending the items built by the hook
+// get the fields converted to rich, if any, with their type, and the trace
+// chain, if any.
+func (j job) report(e error, msg string, m map[string]string, rich map[string]interface{}, stack []uintptr, chain []error) error {
+	if c, ok := j.client.(*httpClient); ok && (rich != nil || chain != nil) {
+		custom := interfaceMap(m)
+		if rich != nil {
+			custom = restoreTypes(m, rich)
+		}
+		_, err := c.report(j.severity, e, stack, custom, chain)
+		return err
+	}
 
 	var err error
-	switch entry.Level {
-	case log.FatalLevel, log.PanicLevel:
-		_, err = j.client.Critical(e, m)
-	case log.ErrorLevel:
-		_, err = j.client.Error(e, m)
-	case log.WarnLevel:
-		_, err = j.client.Warning(e, m)
-	case log.InfoLevel:
-		_, err = j.client.Info(entry.Message, m)
-	case log.DebugLevel:
-		_, err = j.client.Debug(entry.Message, m)
+	switch j.severity {
+	case SeverityCritical:
+		if len(stack) > 0 {
+			_, err = j.client.CriticalStack(e, stack, m)
+		} else {
+			_, err = j.client.Critical(e, m)
+		}
+	case SeverityError:
+		if len(stack) > 0 {
+			_, err = j.client.ErrorStack(e, stack, m)
+		} else {
+			_, err = j.client.Error(e, m)
+		}
+	case SeverityWarning:
+		if len(stack) > 0 {
+			_, err = j.client.WarningStack(e, stack, m)
+		} else {
+			_, err = j.client.Warning(e, m)
+		}
+	case SeverityInfo:
+		_, err = j.client.Info(msg, m)
+	case SeverityDebug:
+		_, err = j.client.Debug(msg, m)
 	default:
-		err = fmt.Errorf("Unknown level: %s", entry.Level)
+		err = fmt.Errorf("Unknown severity %q for level: %s", j.severity, j.entry.Level)
 	}
 
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Could not send entry to rollbar: %v\n", err)
+	return err
+}
+
+// mergeContext adds the values of the well known context keys to m, without
+// overwriting fields that were set on the entry. roll.Client does not accept a
+// context, so cancellation can't be passed on to the report call itself.
+func mergeContext(ctx context.Context, m map[string]string) {
+	if ctx == nil {
+		return
+	}
+
+	for _, key := range contextKeys {
+		v := ctx.Value(key)
+		if v == nil {
+			continue
+		}
+		if _, exists := m[string(key)]; !exists {
+			m[string(key)] = fmt.Sprint(v)
+		}
 	}
 }
 
@@ -54,14 +363,18 @@ type worker struct {
 	jobChannel chan job
 	shutDown   chan struct{}
 	wg         *sync.WaitGroup
+	counters   *counters
+	errorLog   *errorLogger
 }
 
-func newWorker(workerPool chan chan job, shutdown chan struct{}, wg *sync.WaitGroup) *worker {
+func newWorker(workerPool chan chan job, shutdown chan struct{}, wg *sync.WaitGroup, c *counters, l *errorLogger) *worker {
 	return &worker{
 		workerPool: workerPool,
 		shutDown:   shutdown,
 		jobChannel: make(chan job),
 		wg:         wg,
+		counters:   c,
+		errorLog:   l,
 	}
 }
 
@@ -72,7 +385,33 @@ func (w *worker) Work() {
 			w.workerPool <- w.jobChannel
 			select {
 			case job := <-w.jobChannel:
-				job.sendToRollbar()
+				if job.retire {
+					return
+				}
+				start := time.Now()
+				err := job.deliver()
+				if job.acker != nil && settled(err) {
+					job.acker.Ack(job.ackID)
+				}
+				if err == ErrCircuitOpen {
+					w.errorLog.log("entry dropped", err, 0)
+					if job.onDrop != nil {
+						job.onDrop(job.entry, err)
+					}
+				}
+				if skipped(err) {
+					w.counters.drop()
+					continue
+				}
+				if de, ok := err.(*deliveryError); ok {
+					w.errorLog.log("delivery failed", de.err, de.attempts)
+				} else if err != nil {
+					w.errorLog.log("delivery failed", err, 1)
+				}
+				if err != nil && job.onDrop != nil {
+					job.onDrop(job.entry, err)
+				}
+				w.counters.done(err, start)
 			case <-w.shutDown:
 				return
 			}
@@ -0,0 +1,79 @@
+package rollrus
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Writer is an io.Writer reporting every line written to it as an entry
+// fired through a Hook, for libraries that only log to an io.Writer, e.g. the
+// standard log package or the stderr of a subprocess.
+type Writer struct {
+	hook  *Hook
+	level log.Level
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter returns a Writer firing the lines written to it through hook at
+// level. The lines go through the buffer and workers of hook like any other
+// entry, and nothing is reported when hook doesn't handle level.
+func NewWriter(hook *Hook, level log.Level) *Writer {
+	return &Writer{hook: hook, level: level}
+}
+
+// Write fires an entry for every complete line of p. The last line is kept
+// until it is completed by a later Write, or until Close.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.buf = append(w.buf, p...)
+	var lines []string
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	w.mu.Unlock()
+
+	for _, line := range lines {
+		if err := w.fire(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Close fires the last line written, if it was not terminated by a newline.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	line := string(w.buf)
+	w.buf = nil
+	w.mu.Unlock()
+
+	return w.fire(line)
+}
+
+// fire reports line, without its trailing carriage return. Blank lines are
+// skipped.
+func (w *Writer) fire(line string) error {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" || !firesOn(w.hook, w.level) {
+		return nil
+	}
+
+	return w.hook.Fire(&log.Entry{
+		Logger:  log.StandardLogger(),
+		Data:    make(log.Fields),
+		Time:    time.Now(),
+		Level:   w.level,
+		Message: line,
+	})
+}
@@ -0,0 +1,58 @@
+package rollrus
+
+import (
+	"fmt"
+	stdlog "log"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWriter(t *testing.T) {
+	hook := NewTestHookForLevels(RollrusConfig{NumWorkers: 1})
+	defer hook.Close()
+
+	w := NewWriter(hook.Hook, logrus.ErrorLevel)
+	logger := stdlog.New(w, "", 0)
+
+	logger.Print("first")
+	fmt.Fprint(w, "second\r\n\nthi")
+	fmt.Fprint(w, "rd")
+
+	if !hook.WaitForEmpty(time.Second) {
+		t.Fatal("Expected the lines to be sent")
+	}
+
+	if n := len(hook.Entries()); n != 2 {
+		t.Fatalf("Expected the 2 complete lines to be reported, got %d", n)
+	}
+
+	w.Close()
+	hook.WaitForEmpty(time.Second)
+
+	var messages []string
+	for _, item := range hook.Entries() {
+		if item.Level != SeverityError {
+			t.Fatalf("Expected the lines to be reported as errors, got %s", item.Level)
+		}
+		messages = append(messages, item.Message)
+	}
+
+	if fmt.Sprint(messages) != "[first second third]" {
+		t.Fatalf("Unexpected lines: %q", messages)
+	}
+}
+
+func TestWriterLevel(t *testing.T) {
+	hook := NewTestHook()
+	defer hook.Close()
+
+	w := NewWriter(hook.Hook, logrus.InfoLevel)
+	fmt.Fprintln(w, "not reported")
+	hook.WaitForEmpty(time.Second)
+
+	if n := len(hook.Entries()); n != 0 {
+		t.Fatalf("Expected no item at a level the hook doesn't handle, got %d", n)
+	}
+}